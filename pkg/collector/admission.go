@@ -0,0 +1,152 @@
+package collector
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+
+	"sigs.k8s.io/yaml"
+)
+
+const (
+	// ProbeAdmission inspect API server admission plugins and --admission-control-config-file plugins configuration
+	ProbeAdmission = "admission"
+
+	podSecurityPlugin    = "PodSecurity"
+	eventRateLimitPlugin = "EventRateLimit"
+)
+
+// admissionConfiguration apiserver.config.k8s.io AdmissionConfiguration
+type admissionConfiguration struct {
+	Kind    string                  `json:"kind"`
+	Plugins []admissionPluginConfig `json:"plugins"`
+}
+
+type admissionPluginConfig struct {
+	Name          string          `json:"name"`
+	Path          string          `json:"path,omitempty"`
+	Configuration json.RawMessage `json:"configuration,omitempty"`
+}
+
+// AdmissionInfo admission probe result
+type AdmissionInfo struct {
+	EnabledPlugins  []string `json:"enabledPlugins,omitempty"`
+	DisabledPlugins []string `json:"disabledPlugins,omitempty"`
+	// ConfigFile host path of --admission-control-config-file, empty when the flag is not set
+	ConfigFile        string                   `json:"configFile,omitempty"`
+	ConfigFound       bool                     `json:"configFound"`
+	ConfiguredPlugins []string                 `json:"configuredPlugins,omitempty"`
+	PodSecurity       *PodSecurityAdmission    `json:"podSecurity,omitempty"`
+	EventRateLimit    *EventRateLimitAdmission `json:"eventRateLimit,omitempty"`
+}
+
+// PodSecurityAdmission PodSecurity plugin defaults and exemptions
+type PodSecurityAdmission struct {
+	Defaults   map[string]string     `json:"defaults,omitempty"`
+	Exemptions PodSecurityExemptions `json:"exemptions"`
+}
+
+// PodSecurityExemptions PodSecurity plugin exempted usernames, namespaces and runtime classes
+type PodSecurityExemptions struct {
+	Usernames      []string `json:"usernames,omitempty"`
+	Namespaces     []string `json:"namespaces,omitempty"`
+	RuntimeClasses []string `json:"runtimeClasses,omitempty"`
+}
+
+// EventRateLimitAdmission EventRateLimit plugin limits
+type EventRateLimitAdmission struct {
+	Limits []EventRateLimit `json:"limits,omitempty"`
+}
+
+// EventRateLimit EventRateLimit plugin limit per type (Server, Namespace, User or SourceAndObject)
+type EventRateLimit struct {
+	Type      string `json:"type"`
+	QPS       int    `json:"qps"`
+	Burst     int    `json:"burst"`
+	CacheSize int    `json:"cacheSize,omitempty"`
+}
+
+// admissionProbe enabled and disabled admission plugins and PodSecurity defaults of a component config, example: apiserver
+func admissionProbe(ctx ProbeContext, arg string) ([]interface{}, error) {
+	pod, ok, err := probeStaticPod(ctx, arg)
+	if err != nil {
+		return nil, err
+	}
+	if !ok {
+		return []interface{}{}, nil
+	}
+	info := AdmissionInfo{
+		EnabledPlugins:  splitSetting(pod.Flags["--enable-admission-plugins"]),
+		DisabledPlugins: splitSetting(pod.Flags["--disable-admission-plugins"]),
+	}
+	configFile := pod.Flags["--admission-control-config-file"]
+	if configFile == "" {
+		return []interface{}{info}, nil
+	}
+	info.ConfigFile = hostPath(pod, configFile)
+	data, err := os.ReadFile(filepath.Join(ctx.Root, info.ConfigFile))
+	if err != nil {
+		return []interface{}{info}, nil
+	}
+	var config admissionConfiguration
+	if err := yaml.Unmarshal(data, &config); err != nil || config.Kind != "AdmissionConfiguration" {
+		return []interface{}{info}, nil
+	}
+	info.ConfigFound = true
+	for _, plugin := range config.Plugins {
+		info.ConfiguredPlugins = append(info.ConfiguredPlugins, plugin.Name)
+		pluginConfig := admissionPluginData(ctx.Root, info.ConfigFile, pod, plugin)
+		if pluginConfig == nil {
+			continue
+		}
+		switch plugin.Name {
+		case podSecurityPlugin:
+			info.PodSecurity = podSecurityAdmission(pluginConfig)
+		case eventRateLimitPlugin:
+			info.EventRateLimit = eventRateLimitAdmission(pluginConfig)
+		}
+	}
+	return []interface{}{info}, nil
+}
+
+// admissionPluginData plugin inline configuration or path content, a relative path is relative to the admission config file
+func admissionPluginData(root string, configFile string, pod StaticPodInfo, plugin admissionPluginConfig) []byte {
+	if len(plugin.Configuration) > 0 && string(plugin.Configuration) != "null" {
+		return plugin.Configuration
+	}
+	if plugin.Path == "" {
+		return nil
+	}
+	path := plugin.Path
+	if filepath.IsAbs(path) {
+		path = hostPath(pod, path)
+	} else {
+		path = filepath.Join(filepath.Dir(configFile), path)
+	}
+	data, err := os.ReadFile(filepath.Join(root, path))
+	if err != nil {
+		return nil
+	}
+	return data
+}
+
+func podSecurityAdmission(data []byte) *PodSecurityAdmission {
+	var config struct {
+		Defaults   map[string]string     `json:"defaults"`
+		Exemptions PodSecurityExemptions `json:"exemptions"`
+	}
+	if err := yaml.Unmarshal(data, &config); err != nil {
+		return nil
+	}
+	return &PodSecurityAdmission{Defaults: config.Defaults, Exemptions: config.Exemptions}
+}
+
+func eventRateLimitAdmission(data []byte) *EventRateLimitAdmission {
+	var config struct {
+		Limits []EventRateLimit `json:"limits"`
+	}
+	if err := yaml.Unmarshal(data, &config); err != nil {
+		return nil
+	}
+	return &EventRateLimitAdmission{Limits: config.Limits}
+}
@@ -0,0 +1,97 @@
+package collector
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestAdmissionProbe(t *testing.T) {
+	root := writeFiles(t, map[string]string{
+		"/etc/kubernetes/manifests/kube-apiserver.yaml": `apiVersion: v1
+kind: Pod
+metadata:
+  name: kube-apiserver
+spec:
+  containers:
+  - name: kube-apiserver
+    command:
+    - kube-apiserver
+    - --enable-admission-plugins=NodeRestriction,PodSecurity,EventRateLimit
+    - --disable-admission-plugins=AlwaysAdmit
+    - --admission-control-config-file=/etc/kubernetes/admission/admission.yaml
+    volumeMounts:
+    - mountPath: /etc/kubernetes/admission
+      name: admission
+  volumes:
+  - hostPath:
+      path: /etc/kubernetes/admission-config
+    name: admission
+`,
+		"/etc/kubernetes/admission-config/admission.yaml": `apiVersion: apiserver.config.k8s.io/v1
+kind: AdmissionConfiguration
+plugins:
+- name: PodSecurity
+  configuration:
+    apiVersion: pod-security.admission.config.k8s.io/v1
+    kind: PodSecurityConfiguration
+    defaults:
+      enforce: baseline
+      enforce-version: latest
+      warn: restricted
+    exemptions:
+      usernames: ["system:serviceaccount:kube-system:replicaset-controller"]
+      namespaces: ["kube-system"]
+- name: EventRateLimit
+  path: eventconfig.yaml
+- name: ImagePolicyWebhook
+  path: /etc/kubernetes/admission/missing.yaml
+`,
+		"/etc/kubernetes/admission-config/eventconfig.yaml": `apiVersion: eventratelimit.admission.k8s.io/v1alpha1
+kind: Configuration
+limits:
+- type: Server
+  qps: 50
+  burst: 100
+- type: Namespace
+  qps: 10
+  burst: 20
+  cacheSize: 2000
+`,
+	})
+	ctx := ProbeContext{Root: root, Params: map[string]string{"$apiserver.confs": "/etc/kubernetes/manifests/kube-apiserver.yaml"}}
+
+	got, err := admissionProbe(ctx, "apiserver")
+	assert.NoError(t, err)
+	assert.Equal(t, []interface{}{
+		AdmissionInfo{
+			EnabledPlugins:    []string{"NodeRestriction", "PodSecurity", "EventRateLimit"},
+			DisabledPlugins:   []string{"AlwaysAdmit"},
+			ConfigFile:        "/etc/kubernetes/admission-config/admission.yaml",
+			ConfigFound:       true,
+			ConfiguredPlugins: []string{"PodSecurity", "EventRateLimit", "ImagePolicyWebhook"},
+			PodSecurity: &PodSecurityAdmission{
+				Defaults: map[string]string{"enforce": "baseline", "enforce-version": "latest", "warn": "restricted"},
+				Exemptions: PodSecurityExemptions{
+					Usernames:  []string{"system:serviceaccount:kube-system:replicaset-controller"},
+					Namespaces: []string{"kube-system"},
+				},
+			},
+			EventRateLimit: &EventRateLimitAdmission{Limits: []EventRateLimit{
+				{Type: "Server", QPS: 50, Burst: 100},
+				{Type: "Namespace", QPS: 10, Burst: 20, CacheSize: 2000},
+			}},
+		},
+	}, got)
+}
+
+func TestAdmissionProbeWithoutConfig(t *testing.T) {
+	root := writeFiles(t, map[string]string{
+		"/etc/kubernetes/manifests/kube-apiserver.yaml": apiserverManifest,
+	})
+	ctx := ProbeContext{Root: root, Params: map[string]string{"$apiserver.confs": "/etc/kubernetes/manifests/kube-apiserver.yaml"}}
+
+	got, err := admissionProbe(ctx, "")
+	assert.NoError(t, err)
+	assert.Equal(t, []interface{}{AdmissionInfo{}}, got)
+}
@@ -0,0 +1,204 @@
+package collector
+
+import (
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+
+	"sigs.k8s.io/yaml"
+)
+
+const (
+	// ProbeAuditPolicy inspect API server audit policy and audit log set by the apiserver flags
+	ProbeAuditPolicy = "auditpolicy"
+
+	auditLevelRequestResponse = "RequestResponse"
+
+	auditLogStdout = "-"
+)
+
+// auditPolicy audit.k8s.io Policy fields the probe needs
+type auditPolicy struct {
+	Kind  string            `json:"kind"`
+	Rules []auditPolicyRule `json:"rules"`
+}
+
+type auditPolicyRule struct {
+	Level           string               `json:"level"`
+	Users           []string             `json:"users,omitempty"`
+	UserGroups      []string             `json:"userGroups,omitempty"`
+	Verbs           []string             `json:"verbs,omitempty"`
+	Resources       []auditGroupResource `json:"resources,omitempty"`
+	Namespaces      []string             `json:"namespaces,omitempty"`
+	NonResourceURLs []string             `json:"nonResourceURLs,omitempty"`
+}
+
+type auditGroupResource struct {
+	Group         string   `json:"group,omitempty"`
+	Resources     []string `json:"resources,omitempty"`
+	ResourceNames []string `json:"resourceNames,omitempty"`
+}
+
+// AuditInfo auditpolicy probe result
+type AuditInfo struct {
+	// PolicyFile host path of --audit-policy-file, empty when the flag is not set
+	PolicyFile   string         `json:"policyFile,omitempty"`
+	PolicyFound  bool           `json:"policyFound"`
+	RuleCount    int            `json:"ruleCount"`
+	RulesByLevel map[string]int `json:"rulesByLevel,omitempty"`
+	// SecretsLevel level of the first rule matching any secrets request, empty when no rule does
+	SecretsLevel string `json:"secretsLevel,omitempty"`
+	// SecretsAtRequestResponse any rule reached by secrets requests log them at RequestResponse level
+	SecretsAtRequestResponse bool     `json:"secretsAtRequestResponse"`
+	CatchAll                 bool     `json:"catchAll"`
+	Log                      AuditLog `json:"log"`
+}
+
+// AuditLog audit log backend file and rotation flags
+type AuditLog struct {
+	// Path host path of --audit-log-path, - when logging to stdout, empty when the flag is not set
+	Path      string `json:"path,omitempty"`
+	Exists    bool   `json:"exists"`
+	Size      int64  `json:"size,omitempty"`
+	ModTime   string `json:"modTime,omitempty"`
+	MaxAge    *int   `json:"maxAge,omitempty"`
+	MaxBackup *int   `json:"maxBackup,omitempty"`
+	MaxSize   *int   `json:"maxSize,omitempty"`
+}
+
+// auditPolicyProbe audit policy rules and audit log flags of a component config, example: apiserver
+func auditPolicyProbe(ctx ProbeContext, arg string) ([]interface{}, error) {
+	pod, ok, err := probeStaticPod(ctx, arg)
+	if err != nil {
+		return nil, err
+	}
+	if !ok {
+		return []interface{}{}, nil
+	}
+	info := AuditInfo{}
+	if policyFile := pod.Flags["--audit-policy-file"]; policyFile != "" {
+		info.PolicyFile = hostPath(pod, policyFile)
+		if data, err := os.ReadFile(filepath.Join(ctx.Root, info.PolicyFile)); err == nil {
+			var policy auditPolicy
+			if err := yaml.Unmarshal(data, &policy); err == nil && policy.Kind == "Policy" {
+				info.PolicyFound = true
+				inspectAuditPolicy(&info, policy)
+			}
+		}
+	}
+	info.Log = auditLog(ctx.Root, pod)
+	return []interface{}{info}, nil
+}
+
+// hostPath translate a container path to its host path by the longest matching volume mount
+func hostPath(pod StaticPodInfo, path string) string {
+	var mount *VolumeMount
+	for i, m := range pod.VolumeMounts {
+		mp := strings.TrimSuffix(m.MountPath, "/")
+		if m.HostPath == "" || (path != mp && !strings.HasPrefix(path, mp+"/")) {
+			continue
+		}
+		if mount == nil || len(m.MountPath) > len(mount.MountPath) {
+			mount = &pod.VolumeMounts[i]
+		}
+	}
+	if mount == nil {
+		return path
+	}
+	return filepath.Join(mount.HostPath, strings.TrimPrefix(path, strings.TrimSuffix(mount.MountPath, "/")))
+}
+
+func inspectAuditPolicy(info *AuditInfo, policy auditPolicy) {
+	info.RuleCount = len(policy.Rules)
+	info.RulesByLevel = make(map[string]int)
+	secretsDecided := false
+	for _, rule := range policy.Rules {
+		info.RulesByLevel[rule.Level]++
+		if rule.catchAll() {
+			info.CatchAll = true
+		}
+		if secretsDecided || !rule.matchSecrets() {
+			continue
+		}
+		if info.SecretsLevel == "" {
+			info.SecretsLevel = rule.Level
+		}
+		if rule.Level == auditLevelRequestResponse {
+			info.SecretsAtRequestResponse = true
+		}
+		// rules after a rule matching every secrets request are never reached by secrets requests
+		secretsDecided = rule.unconditional()
+	}
+}
+
+// matchSecrets rule may match core group secrets requests
+func (r auditPolicyRule) matchSecrets() bool {
+	if len(r.NonResourceURLs) > 0 {
+		return false
+	}
+	if len(r.Resources) == 0 {
+		return true
+	}
+	for _, gr := range r.Resources {
+		if gr.Group != "" && gr.Group != "*" {
+			continue
+		}
+		if len(gr.Resources) == 0 {
+			return true
+		}
+		for _, resource := range gr.Resources {
+			if resource == "secrets" || resource == "*" || resource == "*/*" {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// unconditional rule is not restricted to some users, verbs, namespaces or resource names
+func (r auditPolicyRule) unconditional() bool {
+	if len(r.Users) > 0 || len(r.UserGroups) > 0 || len(r.Verbs) > 0 || len(r.Namespaces) > 0 {
+		return false
+	}
+	for _, gr := range r.Resources {
+		if len(gr.ResourceNames) > 0 {
+			return false
+		}
+	}
+	return true
+}
+
+// catchAll rule match every request
+func (r auditPolicyRule) catchAll() bool {
+	return len(r.Resources) == 0 && len(r.NonResourceURLs) == 0 && r.unconditional()
+}
+
+func auditLog(root string, pod StaticPodInfo) AuditLog {
+	log := AuditLog{
+		MaxAge:    flagInt(pod.Flags, "--audit-log-maxage"),
+		MaxBackup: flagInt(pod.Flags, "--audit-log-maxbackup"),
+		MaxSize:   flagInt(pod.Flags, "--audit-log-maxsize"),
+	}
+	path := pod.Flags["--audit-log-path"]
+	if path == "" || path == auditLogStdout {
+		log.Path = path
+		return log
+	}
+	log.Path = hostPath(pod, path)
+	if fi, err := os.Stat(filepath.Join(root, log.Path)); err == nil {
+		log.Exists = true
+		log.Size = fi.Size()
+		log.ModTime = fi.ModTime().UTC().Format(time.RFC3339)
+	}
+	return log
+}
+
+func flagInt(flags map[string]string, name string) *int {
+	value, err := strconv.Atoi(flags[name])
+	if err != nil {
+		return nil
+	}
+	return &value
+}
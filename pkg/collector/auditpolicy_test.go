@@ -0,0 +1,139 @@
+package collector
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestAuditPolicyProbe(t *testing.T) {
+	manifest := `apiVersion: v1
+kind: Pod
+metadata:
+  name: kube-apiserver
+spec:
+  containers:
+  - name: kube-apiserver
+    command:
+    - kube-apiserver
+    - --audit-policy-file=/etc/kubernetes/audit/policy.yaml
+    - --audit-log-path=/var/log/kubernetes/audit/audit.log
+    - --audit-log-maxage=30
+    - --audit-log-maxbackup=10
+    - --audit-log-maxsize=100
+    volumeMounts:
+    - mountPath: /etc/kubernetes/audit
+      name: audit-policy
+    - mountPath: /var/log/kubernetes/audit
+      name: audit-log
+  volumes:
+  - hostPath:
+      path: /etc/kubernetes/audit-policies
+    name: audit-policy
+  - hostPath:
+      path: /var/log/kube-audit
+    name: audit-log
+`
+	tests := []struct {
+		name   string
+		policy string
+		want   AuditInfo
+	}{
+		{
+			name: "secrets logged at metadata with catch-all",
+			policy: `apiVersion: audit.k8s.io/v1
+kind: Policy
+rules:
+- level: None
+  users: ["system:kube-proxy"]
+  verbs: ["watch"]
+- level: RequestResponse
+  resources:
+  - group: ""
+    resources: ["secrets"]
+    resourceNames: ["debug"]
+- level: Metadata
+  resources:
+  - group: ""
+    resources: ["secrets", "configmaps"]
+- level: RequestResponse
+  resources:
+  - group: ""
+- level: Metadata
+`,
+			want: AuditInfo{
+				RuleCount:                5,
+				RulesByLevel:             map[string]int{"None": 1, "Metadata": 2, "RequestResponse": 2},
+				SecretsLevel:             "None",
+				SecretsAtRequestResponse: true,
+				CatchAll:                 true,
+			},
+		},
+		{
+			name: "secrets logged at request response",
+			policy: `apiVersion: audit.k8s.io/v1
+kind: Policy
+rules:
+- level: None
+  nonResourceURLs: ["/healthz*"]
+- level: RequestResponse
+  resources:
+  - group: "*"
+    resources: ["*"]
+`,
+			want: AuditInfo{
+				RuleCount:                2,
+				RulesByLevel:             map[string]int{"None": 1, "RequestResponse": 1},
+				SecretsLevel:             "RequestResponse",
+				SecretsAtRequestResponse: true,
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			root := writeFiles(t, map[string]string{
+				"/etc/kubernetes/manifests/kube-apiserver.yaml": manifest,
+				"/etc/kubernetes/audit-policies/policy.yaml":    tt.policy,
+				"/var/log/kube-audit/audit.log":                 "{}\n",
+			})
+			modTime := time.Date(2024, 6, 1, 0, 0, 0, 0, time.UTC)
+			assert.NoError(t, os.Chtimes(filepath.Join(root, "/var/log/kube-audit/audit.log"), modTime, modTime))
+			ctx := ProbeContext{Root: root, Params: map[string]string{"$apiserver.confs": "/etc/kubernetes/manifests/kube-apiserver.yaml"}}
+
+			got, err := auditPolicyProbe(ctx, "apiserver")
+			assert.NoError(t, err)
+			want := tt.want
+			want.PolicyFile = "/etc/kubernetes/audit-policies/policy.yaml"
+			want.PolicyFound = true
+			maxAge, maxBackup, maxSize := 30, 10, 100
+			want.Log = AuditLog{
+				Path:      "/var/log/kube-audit/audit.log",
+				Exists:    true,
+				Size:      3,
+				ModTime:   "2024-06-01T00:00:00Z",
+				MaxAge:    &maxAge,
+				MaxBackup: &maxBackup,
+				MaxSize:   &maxSize,
+			}
+			assert.Equal(t, []interface{}{want}, got)
+		})
+	}
+}
+
+func TestAuditPolicyProbeWithoutAudit(t *testing.T) {
+	root := writeFiles(t, map[string]string{
+		"/etc/kubernetes/manifests/kube-apiserver.yaml": apiserverManifest,
+	})
+	ctx := ProbeContext{Root: root, Params: map[string]string{"$apiserver.confs": "/etc/kubernetes/manifests/kube-apiserver.yaml"}}
+
+	got, err := auditPolicyProbe(ctx, "")
+	assert.NoError(t, err)
+	assert.Equal(t, []interface{}{AuditInfo{}}, got)
+
+	got, err = auditPolicyProbe(ProbeContext{Root: root}, "apiserver")
+	assert.NoError(t, err)
+	assert.Equal(t, []interface{}{}, got)
+}
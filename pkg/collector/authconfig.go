@@ -0,0 +1,156 @@
+package collector
+
+import (
+	"os"
+	"path/filepath"
+
+	"sigs.k8s.io/yaml"
+)
+
+const (
+	// ProbeAuthConfig inspect API server structured authentication and authorization config and static credential files
+	ProbeAuthConfig = "authconfig"
+)
+
+// staticCredentialFlags flags referencing static credential files
+var staticCredentialFlags = []string{"--token-auth-file", "--basic-auth-file"}
+
+// authenticationConfiguration apiserver.config.k8s.io AuthenticationConfiguration fields the probe needs
+type authenticationConfiguration struct {
+	Kind string `json:"kind"`
+	JWT  []struct {
+		Issuer struct {
+			URL       string   `json:"url"`
+			Audiences []string `json:"audiences"`
+		} `json:"issuer"`
+	} `json:"jwt"`
+	Anonymous *struct {
+		Enabled    bool `json:"enabled"`
+		Conditions []struct {
+			Path string `json:"path"`
+		} `json:"conditions"`
+	} `json:"anonymous"`
+}
+
+// authorizationConfiguration apiserver.config.k8s.io AuthorizationConfiguration fields the probe needs
+type authorizationConfiguration struct {
+	Kind        string `json:"kind"`
+	Authorizers []struct {
+		Type    string `json:"type"`
+		Name    string `json:"name"`
+		Webhook *struct {
+			FailurePolicy string `json:"failurePolicy"`
+		} `json:"webhook"`
+	} `json:"authorizers"`
+}
+
+// AuthConfigInfo authconfig probe result, certificate authorities and credentials are never reported
+type AuthConfigInfo struct {
+	// AuthenticationConfig host path of --authentication-config, empty when the flag is not set
+	AuthenticationConfig      string      `json:"authenticationConfig,omitempty"`
+	AuthenticationConfigFound bool        `json:"authenticationConfigFound"`
+	JWTIssuers                []JWTIssuer `json:"jwtIssuers,omitempty"`
+	// AnonymousEnabled anonymous section enabled, unset when the config has no anonymous section
+	AnonymousEnabled *bool    `json:"anonymousEnabled,omitempty"`
+	AnonymousPaths   []string `json:"anonymousPaths,omitempty"`
+	// AuthorizationConfig host path of --authorization-config, empty when the flag is not set
+	AuthorizationConfig      string `json:"authorizationConfig,omitempty"`
+	AuthorizationConfigFound bool   `json:"authorizationConfigFound"`
+	// Authorizers ordered authorizers of the authorization config, or of --authorization-mode when not set
+	Authorizers           []Authorizer           `json:"authorizers,omitempty"`
+	StaticCredentialFiles []StaticCredentialFile `json:"staticCredentialFiles,omitempty"`
+}
+
+// JWTIssuer authentication config JWT issuer
+type JWTIssuer struct {
+	URL       string   `json:"url"`
+	Audiences []string `json:"audiences,omitempty"`
+}
+
+// Authorizer authorization chain entry
+type Authorizer struct {
+	Type          string `json:"type"`
+	Name          string `json:"name,omitempty"`
+	FailurePolicy string `json:"failurePolicy,omitempty"`
+}
+
+// StaticCredentialFile static credential file flag and whether the file exists
+type StaticCredentialFile struct {
+	Flag   string `json:"flag"`
+	Path   string `json:"path"`
+	Exists bool   `json:"exists"`
+}
+
+// authConfigProbe authentication and authorization config files of a component config, example: apiserver
+func authConfigProbe(ctx ProbeContext, arg string) ([]interface{}, error) {
+	pod, ok, err := probeStaticPod(ctx, arg)
+	if err != nil {
+		return nil, err
+	}
+	if !ok {
+		return []interface{}{}, nil
+	}
+	info := AuthConfigInfo{}
+	if configFile := pod.Flags["--authentication-config"]; configFile != "" {
+		info.AuthenticationConfig = hostPath(pod, configFile)
+		inspectAuthenticationConfig(ctx.Root, &info)
+	}
+	if configFile := pod.Flags["--authorization-config"]; configFile != "" {
+		info.AuthorizationConfig = hostPath(pod, configFile)
+		inspectAuthorizationConfig(ctx.Root, &info)
+	} else {
+		for _, mode := range splitSetting(pod.Flags["--authorization-mode"]) {
+			info.Authorizers = append(info.Authorizers, Authorizer{Type: mode})
+		}
+	}
+	for _, flag := range staticCredentialFlags {
+		path := pod.Flags[flag]
+		if path == "" {
+			continue
+		}
+		path = hostPath(pod, path)
+		_, err := os.Stat(filepath.Join(ctx.Root, path))
+		info.StaticCredentialFiles = append(info.StaticCredentialFiles, StaticCredentialFile{Flag: flag, Path: path, Exists: err == nil})
+	}
+	return []interface{}{info}, nil
+}
+
+func inspectAuthenticationConfig(root string, info *AuthConfigInfo) {
+	data, err := os.ReadFile(filepath.Join(root, info.AuthenticationConfig))
+	if err != nil {
+		return
+	}
+	var config authenticationConfiguration
+	if err := yaml.Unmarshal(data, &config); err != nil || config.Kind != "AuthenticationConfiguration" {
+		return
+	}
+	info.AuthenticationConfigFound = true
+	for _, jwt := range config.JWT {
+		info.JWTIssuers = append(info.JWTIssuers, JWTIssuer{URL: jwt.Issuer.URL, Audiences: jwt.Issuer.Audiences})
+	}
+	if config.Anonymous != nil {
+		info.AnonymousEnabled = boolPtr(config.Anonymous.Enabled)
+		for _, c := range config.Anonymous.Conditions {
+			info.AnonymousPaths = append(info.AnonymousPaths, c.Path)
+		}
+	}
+}
+
+func inspectAuthorizationConfig(root string, info *AuthConfigInfo) {
+	data, err := os.ReadFile(filepath.Join(root, info.AuthorizationConfig))
+	if err != nil {
+		return
+	}
+	var config authorizationConfiguration
+	if err := yaml.Unmarshal(data, &config); err != nil || config.Kind != "AuthorizationConfiguration" {
+		return
+	}
+	info.AuthorizationConfigFound = true
+	for _, a := range config.Authorizers {
+		authorizer := Authorizer{Type: a.Type, Name: a.Name}
+		if a.Webhook != nil {
+			authorizer.FailurePolicy = a.Webhook.FailurePolicy
+		}
+		info.Authorizers = append(info.Authorizers, authorizer)
+	}
+}
@@ -0,0 +1,122 @@
+package collector
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestAuthConfigProbe(t *testing.T) {
+	tests := []struct {
+		name  string
+		flags string
+		files map[string]string
+		want  AuthConfigInfo
+	}{
+		{
+			name: "structured config",
+			flags: `    - --authentication-config=/etc/kubernetes/auth/authn.yaml
+    - --authorization-config=/etc/kubernetes/auth/authz.yaml
+    - --token-auth-file=/etc/kubernetes/auth/tokens.csv
+    - --basic-auth-file=/etc/kubernetes/auth/basic.csv
+`,
+			files: map[string]string{
+				"/etc/kubernetes/auth/authn.yaml": `apiVersion: apiserver.config.k8s.io/v1beta1
+kind: AuthenticationConfiguration
+jwt:
+- issuer:
+    url: https://issuer.example.com
+    audiences: ["kubernetes"]
+    certificateAuthority: |
+      -----BEGIN CERTIFICATE-----
+      c2VjcmV0
+      -----END CERTIFICATE-----
+  claimMappings:
+    username:
+      claim: sub
+      prefix: "oidc:"
+anonymous:
+  enabled: true
+  conditions:
+  - path: /livez
+  - path: /readyz
+`,
+				"/etc/kubernetes/auth/authz.yaml": `apiVersion: apiserver.config.k8s.io/v1beta1
+kind: AuthorizationConfiguration
+authorizers:
+- type: Node
+  name: node
+- type: Webhook
+  name: policy
+  webhook:
+    failurePolicy: NoOpinion
+    connectionInfo:
+      type: KubeConfigFile
+      kubeConfigFile: /etc/kubernetes/auth/webhook.conf
+- type: RBAC
+  name: rbac
+`,
+				"/etc/kubernetes/auth/tokens.csv": "secret-token,admin,1\n",
+			},
+			want: AuthConfigInfo{
+				AuthenticationConfig:      "/etc/kubernetes/auth/authn.yaml",
+				AuthenticationConfigFound: true,
+				JWTIssuers:                []JWTIssuer{{URL: "https://issuer.example.com", Audiences: []string{"kubernetes"}}},
+				AnonymousEnabled:          boolPtr(true),
+				AnonymousPaths:            []string{"/livez", "/readyz"},
+				AuthorizationConfig:       "/etc/kubernetes/auth/authz.yaml",
+				AuthorizationConfigFound:  true,
+				Authorizers: []Authorizer{
+					{Type: "Node", Name: "node"},
+					{Type: "Webhook", Name: "policy", FailurePolicy: "NoOpinion"},
+					{Type: "RBAC", Name: "rbac"},
+				},
+				StaticCredentialFiles: []StaticCredentialFile{
+					{Flag: "--token-auth-file", Path: "/etc/kubernetes/auth/tokens.csv", Exists: true},
+					{Flag: "--basic-auth-file", Path: "/etc/kubernetes/auth/basic.csv"},
+				},
+			},
+		},
+		{
+			name: "authorization mode flag",
+			flags: `    - --authorization-mode=Node,RBAC
+    - --authentication-config=/etc/kubernetes/auth/missing.yaml
+`,
+			want: AuthConfigInfo{
+				AuthenticationConfig: "/etc/kubernetes/auth/missing.yaml",
+				Authorizers:          []Authorizer{{Type: "Node"}, {Type: "RBAC"}},
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			files := map[string]string{
+				"/etc/kubernetes/manifests/kube-apiserver.yaml": `apiVersion: v1
+kind: Pod
+metadata:
+  name: kube-apiserver
+spec:
+  containers:
+  - name: kube-apiserver
+    command:
+    - kube-apiserver
+` + tt.flags,
+			}
+			for name, content := range tt.files {
+				files[name] = content
+			}
+			ctx := ProbeContext{Root: writeFiles(t, files), Params: map[string]string{"$apiserver.confs": "/etc/kubernetes/manifests/kube-apiserver.yaml"}}
+
+			got, err := authConfigProbe(ctx, "apiserver")
+			assert.NoError(t, err)
+			assert.Equal(t, []interface{}{tt.want}, got)
+
+			// certificate authorities and credentials are never emitted
+			data, err := json.Marshal(got)
+			assert.NoError(t, err)
+			assert.NotContains(t, string(data), "c2VjcmV0")
+			assert.NotContains(t, string(data), "secret-token")
+		})
+	}
+}
@@ -0,0 +1,216 @@
+package collector
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strings"
+	"syscall"
+)
+
+const (
+	cacheFileName = "node-collector-cache.json"
+	cacheVersion  = "v1"
+)
+
+var (
+	// cachePathRe absolute paths referenced by an audit command, awk and sed expressions such as /:$/ are not paths
+	cachePathRe = regexp.MustCompile(`/[\w.*?\[-][^\s'"|;&<>()$,=:]*`)
+	// uncacheableAuditRe audit commands which output depend on processes or kernel state rather than files
+	uncacheableAuditRe = regexp.MustCompile(`\bps\b|\bpgrep\b|\bsystemctl\b|/proc/|/sys/`)
+	// cacheableProbes probes which output only depend on their arg and component config paths, kubelet flags
+	// are read from the systemd unit, its drop-ins and environment files and are not cacheable
+	cacheableProbes = map[string]bool{
+		ProbeStaticPod: true,
+		ProbeFlag:      true,
+	}
+	// cacheIgnoredPaths paths referenced by redirections which are not command inputs
+	cacheIgnoredPaths = map[string]bool{
+		"/dev/null": true,
+	}
+)
+
+// resultCache command results keyed by audit and fingerprinted by the referenced paths state
+type resultCache struct {
+	path    string
+	Version string                 `json:"version"`
+	Entries map[string]*cacheEntry `json:"entries"`
+}
+
+type cacheEntry struct {
+	Fingerprint string `json:"fingerprint"`
+	Info        *Info  `json:"info"`
+}
+
+// loadResultCache load cache file from dir, a missing or unreadable cache file start an empty cache
+func loadResultCache(dir string) (*resultCache, error) {
+	if err := os.MkdirAll(dir, 0o700); err != nil {
+		return nil, fmt.Errorf("failed to create cache dir: %w", err)
+	}
+	cache := &resultCache{path: filepath.Join(dir, cacheFileName), Version: cacheVersion, Entries: make(map[string]*cacheEntry)}
+	data, err := os.ReadFile(cache.path)
+	if err != nil {
+		return cache, nil
+	}
+	var stored resultCache
+	if err := json.Unmarshal(data, &stored); err != nil || stored.Version != cacheVersion || stored.Entries == nil {
+		return cache, nil
+	}
+	cache.Entries = stored.Entries
+	return cache, nil
+}
+
+// save write cache file atomically
+func (rc *resultCache) save() error {
+	data, err := json.Marshal(rc)
+	if err != nil {
+		return err
+	}
+	tmp := rc.path + ".tmp"
+	if err := os.WriteFile(tmp, data, 0o600); err != nil {
+		return err
+	}
+	return os.Rename(tmp, rc.path)
+}
+
+// lookup split commands into cached infos and commands to execute, fingerprints of the commands to execute are returned to store their results
+func (rc *resultCache) lookup(root string, params map[string]string, commands []Command) (map[string]*Info, []Command, map[string]string) {
+	cached := make(map[string]*Info)
+	misses := make([]Command, 0)
+	fingerprints := make(map[string]string)
+	entries := make(map[string]*cacheEntry)
+	for _, c := range commands {
+		fingerprint, ok := commandFingerprint(root, params, c)
+		if !ok {
+			misses = append(misses, c)
+			continue
+		}
+		key := cacheKey(c)
+		if entry, found := rc.Entries[key]; found && entry.Fingerprint == fingerprint && entry.Info != nil {
+			entries[key] = entry
+			cached[c.Key] = &Info{Values: entry.Info.Values, Redacted: entry.Info.Redacted, Cached: true}
+			continue
+		}
+		misses = append(misses, c)
+		fingerprints[c.Key] = fingerprint
+	}
+	// entries of commands no longer executed are dropped
+	rc.Entries = entries
+	return cached, misses, fingerprints
+}
+
+// store executed commands results with their fingerprint
+func (rc *resultCache) store(commands []Command, fingerprints map[string]string, nodeInfo map[string]*Info) {
+	for _, c := range commands {
+		fingerprint, ok := fingerprints[c.Key]
+		info, found := nodeInfo[c.Key]
+		if !ok || !found || info.Error != "" {
+			continue
+		}
+		rc.Entries[cacheKey(c)] = &cacheEntry{Fingerprint: fingerprint, Info: &Info{Values: info.Values, Redacted: info.Redacted}}
+	}
+}
+
+// cacheKey command key, probe and substituted audit
+func cacheKey(c Command) string {
+	sum := sha256.Sum256([]byte(strings.Join([]string{c.Key, c.Probe, c.Audit}, "\x00")))
+	return hex.EncodeToString(sum[:])
+}
+
+// commandFingerprint hash of the referenced paths state, commands without referenced paths or depending on
+// processes and kernel state are not cacheable
+func commandFingerprint(root string, params map[string]string, c Command) (string, bool) {
+	if c.Probe != "" && (!cacheableProbes[c.Probe] || probeReferences(c.Audit, kubeletComponent)) {
+		return "", false
+	}
+	if c.Probe == "" && uncacheableAuditRe.MatchString(c.Audit) {
+		return "", false
+	}
+	paths := commandPaths(params, c)
+	if len(paths) == 0 {
+		return "", false
+	}
+	h := sha256.New()
+	for _, path := range paths {
+		fmt.Fprintf(h, "%s\x00%s\x00", path, pathFingerprint(root, path))
+	}
+	return hex.EncodeToString(h.Sum(nil)), true
+}
+
+// commandPaths sorted paths referenced by the audit, glob patterns are expanded, probes also reference
+// their components config paths, example: staticpod probe apiserver and $apiserver.confs
+func commandPaths(params map[string]string, c Command) []string {
+	seen := make(map[string]bool)
+	add := func(path string) {
+		if !cacheIgnoredPaths[path] {
+			seen[path] = true
+		}
+	}
+	for _, path := range cachePathRe.FindAllString(c.Audit, -1) {
+		add(path)
+	}
+	if c.Probe != "" {
+		for name, value := range params {
+			component, _, _ := strings.Cut(strings.TrimPrefix(name, "$"), ".")
+			if filepath.IsAbs(value) && probeReferences(c.Audit, component) {
+				add(value)
+			}
+		}
+	}
+	paths := make([]string, 0, len(seen))
+	for path := range seen {
+		paths = append(paths, path)
+	}
+	sort.Strings(paths)
+	return paths
+}
+
+// pathFingerprint path state, glob patterns fingerprint their matches
+func pathFingerprint(root string, path string) string {
+	if strings.ContainsAny(path, "*?[") {
+		matches := probePaths(root, []string{path})
+		parts := make([]string, 0, len(matches))
+		for _, m := range matches {
+			parts = append(parts, m+"="+pathFingerprint(root, m))
+		}
+		return strings.Join(parts, ";")
+	}
+	state := statePath(root, path)
+	if !state.exists {
+		return "missing"
+	}
+	fingerprint := fmt.Sprintf("%d:%d:%d:%s:%d:%d:%s", state.inode, state.modTime.UnixNano(), state.size, state.mode, state.uid, state.gid, state.hash)
+	if state.mode.IsDir() {
+		fingerprint += ";" + treeFingerprint(filepath.Join(root, path))
+	}
+	return fingerprint
+}
+
+// treeFingerprint state of the entries under dir, audits listing a directory recursively depend on its
+// children permissions and ownership, example: stat -c %a $(ls -aR /etc/kubernetes/pki ...)
+func treeFingerprint(dir string) string {
+	parts := make([]string, 0)
+	_ = filepath.WalkDir(dir, func(path string, d fs.DirEntry, err error) error {
+		if err != nil || path == dir {
+			return nil
+		}
+		fi, err := d.Info()
+		if err != nil {
+			return nil
+		}
+		var uid, gid uint32
+		if st, ok := fi.Sys().(*syscall.Stat_t); ok {
+			uid, gid = st.Uid, st.Gid
+		}
+		rel, _ := filepath.Rel(dir, path)
+		parts = append(parts, fmt.Sprintf("%s=%s:%d:%d:%d:%d", rel, fi.Mode(), uid, gid, fi.Size(), fi.ModTime().UnixNano()))
+		return nil
+	})
+	return strings.Join(parts, ";")
+}
@@ -0,0 +1,204 @@
+package collector
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// countingShell fake shell recording executed commands
+type countingShell struct {
+	fakeShell
+	executed *[]string
+}
+
+func (c countingShell) Run(commandArgs string) (string, int, error) {
+	*c.executed = append(*c.executed, commandArgs)
+	return c.fakeShell.Run(commandArgs)
+}
+
+func TestNodeCollectorCache(t *testing.T) {
+	root := writeFiles(t, map[string]string{
+		"/var/lib/kubelet/config.yaml":                  "readOnlyPort: 0\n",
+		"/etc/kubernetes/manifests/kube-apiserver.yaml": apiserverManifest,
+	})
+	cacheDir := filepath.Join(t.TempDir(), "cache")
+	redactor, err := newRedactor(nil)
+	assert.NoError(t, err)
+	executed := make([]string, 0)
+	newCollector := func() *nodeCollector {
+		cache, err := loadResultCache(cacheDir)
+		assert.NoError(t, err)
+		return &nodeCollector{
+			hostRoot: root,
+			shell: countingShell{fakeShell: fakeShell{
+				"stat -c %a /var/lib/kubelet/config.yaml": "600",
+				"ps -ef": "kubelet --token=abc",
+			}, executed: &executed},
+			params: map[string]string{
+				"$kubelet.confs":   "/var/lib/kubelet/config.yaml",
+				"$apiserver.confs": "/etc/kubernetes/manifests/kube-apiserver.yaml",
+			},
+			commands: []Command{
+				{Key: "kubeletConfFilePermissions", Audit: "stat -c %a /var/lib/kubelet/config.yaml"},
+				{Key: "kubeletProcess", Audit: "ps -ef | grep kubelet"},
+				{Key: "kubeAPIServerAnonymousAuthFlag", Probe: ProbeFlag, Audit: `apiserver.flags["--anonymous-auth"]`},
+			},
+			redactor: redactor,
+			cache:    cache,
+		}
+	}
+
+	nodeInfo, err := newCollector().executeCommands(nil)
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"stat -c %a /var/lib/kubelet/config.yaml", "ps -ef | grep kubelet"}, executed)
+	assert.Equal(t, map[string]*Info{
+		"kubeletConfFilePermissions":     {Values: []interface{}{600}},
+		"kubeletProcess":                 {Values: []interface{}{"kubelet --token=[REDACTED]"}, Redacted: []string{"flag"}},
+		"kubeAPIServerAnonymousAuthFlag": {Values: []interface{}{"false"}},
+	}, nodeInfo)
+
+	// second collection read unchanged files results from the cache file, processes are always listed
+	executed = executed[:0]
+	observed := make(map[string]bool)
+	nodeInfo, err = newCollector().executeCommands(func(key string, _ time.Duration, failed bool) {
+		observed[key] = !failed
+	})
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"ps -ef | grep kubelet"}, executed)
+	assert.Equal(t, map[string]bool{"kubeletConfFilePermissions": true, "kubeletProcess": true, "kubeAPIServerAnonymousAuthFlag": true}, observed)
+	assert.True(t, nodeInfo["kubeletConfFilePermissions"].Cached)
+	assert.Equal(t, []interface{}{float64(600)}, nodeInfo["kubeletConfFilePermissions"].Values)
+	assert.True(t, nodeInfo["kubeAPIServerAnonymousAuthFlag"].Cached)
+	assert.Equal(t, []interface{}{"false"}, nodeInfo["kubeAPIServerAnonymousAuthFlag"].Values)
+	assert.False(t, nodeInfo["kubeletProcess"].Cached)
+
+	// changed manifest invalidate the flag probe result only
+	manifest := strings.Replace(apiserverManifest, "--anonymous-auth=false", "--anonymous-auth=true", 1)
+	assert.NoError(t, os.WriteFile(filepath.Join(root, "/etc/kubernetes/manifests/kube-apiserver.yaml"), []byte(manifest), 0o600))
+	executed = executed[:0]
+	nodeInfo, err = newCollector().executeCommands(nil)
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"ps -ef | grep kubelet"}, executed)
+	assert.True(t, nodeInfo["kubeletConfFilePermissions"].Cached)
+	assert.Equal(t, &Info{Values: []interface{}{"true"}}, nodeInfo["kubeAPIServerAnonymousAuthFlag"])
+
+	// changed permissions invalidate the stat command result
+	assert.NoError(t, os.Chmod(filepath.Join(root, "/var/lib/kubelet/config.yaml"), 0o644))
+	executed = executed[:0]
+	nodeInfo, err = newCollector().executeCommands(nil)
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"stat -c %a /var/lib/kubelet/config.yaml", "ps -ef | grep kubelet"}, executed)
+	assert.False(t, nodeInfo["kubeletConfFilePermissions"].Cached)
+}
+
+func TestCommandFingerprint(t *testing.T) {
+	root := writeFiles(t, map[string]string{
+		"/etc/kubernetes/pki/ca.crt":                    "ca",
+		"/etc/kubernetes/pki/apiserver.crt":             "apiserver",
+		"/etc/kubernetes/manifests/kube-apiserver.yaml": apiserverManifest,
+	})
+	params := map[string]string{"$apiserver.confs": "/etc/kubernetes/manifests/kube-apiserver.yaml"}
+	tests := []struct {
+		name      string
+		command   Command
+		paths     []string
+		cacheable bool
+	}{
+		{
+			name:      "audit paths",
+			command:   Command{Key: "k", Audit: "stat -c %U:%G /etc/kubernetes/pki/ca.crt 2>/dev/null"},
+			paths:     []string{"/etc/kubernetes/pki/ca.crt"},
+			cacheable: true,
+		},
+		{
+			name:      "audit glob",
+			command:   Command{Key: "k", Audit: "stat -c %a /etc/kubernetes/pki/*.crt"},
+			paths:     []string{"/etc/kubernetes/pki/*.crt"},
+			cacheable: true,
+		},
+		{
+			name:      "probe component config",
+			command:   Command{Key: "k", Probe: ProbeFlag, Audit: `apiserver.flags["--profiling"]`},
+			paths:     []string{"/etc/kubernetes/manifests/kube-apiserver.yaml"},
+			cacheable: true,
+		},
+		{
+			name:    "kubelet flag read from systemd unit",
+			command: Command{Key: "k", Probe: ProbeFlag, Audit: `kubelet.flags["--read-only-port"]`},
+			paths:   []string{},
+		},
+		{
+			name:    "process listing",
+			command: Command{Key: "k", Audit: "ps -ef | grep kube-apiserver | grep -o ' --profiling=[^\" ]*'"},
+			paths:   []string{},
+		},
+		{
+			name:    "kernel state probe",
+			command: Command{Key: "k", Probe: ProbeSysctl, Audit: "net.ipv4.ip_forward"},
+			paths:   []string{},
+		},
+		{
+			name:    "no referenced path",
+			command: Command{Key: "k", Audit: "echo 1"},
+			paths:   []string{},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.paths, commandPaths(params, tt.command))
+			fingerprint, ok := commandFingerprint(root, params, tt.command)
+			assert.Equal(t, tt.cacheable, ok)
+			assert.Equal(t, tt.cacheable, fingerprint != "")
+		})
+	}
+}
+
+func TestCommandFingerprintDirectoryTree(t *testing.T) {
+	root := writeFiles(t, map[string]string{
+		"/etc/kubernetes/pki/ca.crt":        "ca",
+		"/etc/kubernetes/pki/apiserver.key": "key",
+		"/etc/kubernetes/pki/etcd/peer.key": "key",
+	})
+	command := Command{Key: "kubePKIKeyFilePermissions", Audit: `stat -c %a $(ls -aR /etc/kubernetes/pki | awk '/:$/&&f{s=$0;f=0}/:$/&&!f{sub(/:$/,"");s=$0;f=1;next}NF&&f{print s"/"$0}' | grep \.key$)`}
+	assert.Equal(t, []string{"/etc/kubernetes/pki"}, commandPaths(nil, command))
+	fingerprint := func() string {
+		f, ok := commandFingerprint(root, nil, command)
+		assert.True(t, ok)
+		return f
+	}
+
+	before := fingerprint()
+	assert.NoError(t, os.Chmod(filepath.Join(root, "/etc/kubernetes/pki/apiserver.key"), 0o644))
+	afterChmod := fingerprint()
+	assert.NotEqual(t, before, afterChmod)
+	assert.NoError(t, os.Chmod(filepath.Join(root, "/etc/kubernetes/pki/etcd/peer.key"), 0o644))
+	assert.NotEqual(t, afterChmod, fingerprint())
+}
+
+func TestLoadResultCache(t *testing.T) {
+	dir := t.TempDir()
+	cache, err := loadResultCache(dir)
+	assert.NoError(t, err)
+	assert.Empty(t, cache.Entries)
+	cache.Entries["key"] = &cacheEntry{Fingerprint: "f", Info: &Info{Values: []interface{}{"v"}}}
+	assert.NoError(t, cache.save())
+
+	cache, err = loadResultCache(dir)
+	assert.NoError(t, err)
+	assert.Equal(t, map[string]*cacheEntry{"key": {Fingerprint: "f", Info: &Info{Values: []interface{}{"v"}}}}, cache.Entries)
+
+	assert.NoError(t, os.WriteFile(filepath.Join(dir, cacheFileName), []byte(`{"version":"v0","entries":{"key":{}}}`), 0o600))
+	cache, err = loadResultCache(dir)
+	assert.NoError(t, err)
+	assert.Empty(t, cache.Entries)
+
+	assert.NoError(t, os.WriteFile(filepath.Join(dir, cacheFileName), []byte("not json"), 0o600))
+	cache, err = loadResultCache(dir)
+	assert.NoError(t, err)
+	assert.Empty(t, cache.Entries)
+}
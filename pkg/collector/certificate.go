@@ -0,0 +1,295 @@
+package collector
+
+import (
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/ed25519"
+	"crypto/rsa"
+	"crypto/x509"
+	"encoding/pem"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+
+	"gopkg.in/yaml.v3"
+)
+
+const (
+	// ProbeCertificate inspect PEM certificates and their private keys
+	ProbeCertificate = "certificate"
+
+	certificateCAPrefix = "ca="
+	// certificateKubeletCA ca= value selecting the kubelet client CA file set in the kubelet flags or config
+	certificateKubeletCA = "kubelet"
+
+	minRSAKeySize   = 2048
+	minECDSAKeySize = 256
+)
+
+var weakSignatureAlgorithms = map[x509.SignatureAlgorithm]bool{
+	x509.MD2WithRSA:    true,
+	x509.MD5WithRSA:    true,
+	x509.SHA1WithRSA:   true,
+	x509.DSAWithSHA1:   true,
+	x509.ECDSAWithSHA1: true,
+}
+
+// CertificateInfo certificate probe result
+type CertificateInfo struct {
+	Path               string   `json:"path"`
+	Subject            string   `json:"subject"`
+	Issuer             string   `json:"issuer"`
+	SANs               []string `json:"sans,omitempty"`
+	NotBefore          string   `json:"notBefore"`
+	NotAfter           string   `json:"notAfter"`
+	DaysToExpiry       int      `json:"daysToExpiry"`
+	Expired            bool     `json:"expired"`
+	KeyAlgorithm       string   `json:"keyAlgorithm"`
+	KeySize            int      `json:"keySize"`
+	SignatureAlgorithm string   `json:"signatureAlgorithm"`
+	// KeyMatch whether private key in the same file or in sibling .key file match the certificate, unset when no key found
+	KeyMatch *bool `json:"keyMatch,omitempty"`
+	// ChainsToCA whether certificate chains to the cluster CA, unset when no CA given or CA can not be read
+	ChainsToCA *bool    `json:"chainsToCA,omitempty"`
+	Weaknesses []string `json:"weaknesses,omitempty"`
+}
+
+// certificateProbe inspect comma separated PEM certificate files or glob patterns, example:
+// /etc/kubernetes/pki/*.crt,ca=/etc/kubernetes/pki/ca.crt, the ca= entry set the cluster CA certificates the certificates
+// must chain to, ca=kubelet use the kubelet client CA file. files which can not be read or do not hold a certificate are skipped
+func certificateProbe(ctx ProbeContext, arg string) ([]interface{}, error) {
+	var roots *x509.CertPool
+	patterns := make([]string, 0)
+	for _, entry := range strings.Split(arg, ",") {
+		entry = strings.TrimSpace(entry)
+		switch {
+		case entry == "":
+		case entry == certificateCAPrefix+certificateKubeletCA:
+			roots = caPool(ctx.Root, kubeletClientCAFile(ctx))
+		case strings.HasPrefix(entry, certificateCAPrefix):
+			roots = caPool(ctx.Root, strings.TrimPrefix(entry, certificateCAPrefix))
+		default:
+			patterns = append(patterns, entry)
+		}
+	}
+	values := make([]interface{}, 0)
+	for _, path := range probePaths(ctx.Root, patterns) {
+		info, ok := inspectCertificate(ctx, path, roots)
+		if ok {
+			values = append(values, info)
+		}
+	}
+	return values, nil
+}
+
+// probePaths expand patterns under root, sorted and returned relative to root
+func probePaths(root string, patterns []string) []string {
+	seen := make(map[string]bool)
+	paths := make([]string, 0)
+	for _, pattern := range patterns {
+		matches, err := filepath.Glob(filepath.Join(root, pattern))
+		if err != nil {
+			continue
+		}
+		for _, m := range matches {
+			path := "/" + strings.TrimPrefix(strings.TrimPrefix(m, filepath.Clean(root)), "/")
+			if !seen[path] {
+				seen[path] = true
+				paths = append(paths, path)
+			}
+		}
+	}
+	sort.Strings(paths)
+	return paths
+}
+
+// kubeletClientCAFile kubelet --client-ca-file flag of its systemd unit, or authentication.x509.clientCAFile of its
+// config file or --client-ca-file of its args file, empty when not set
+func kubeletClientCAFile(ctx ProbeContext) string {
+	if info, ok := loadSystemdUnit(ctx.Root, systemdUnitName(kubeletComponent)); ok && info.Flags["--client-ca-file"] != "" {
+		return info.Flags["--client-ca-file"]
+	}
+	path := ctx.Params["$kubelet.confs"]
+	if path == "" {
+		return ""
+	}
+	data, err := os.ReadFile(filepath.Join(ctx.Root, path))
+	if err != nil {
+		return ""
+	}
+	var config struct {
+		Authentication struct {
+			X509 struct {
+				ClientCAFile string `yaml:"clientCAFile"`
+			} `yaml:"x509"`
+		} `yaml:"authentication"`
+	}
+	if err := yaml.Unmarshal(data, &config); err == nil && config.Authentication.X509.ClientCAFile != "" {
+		return config.Authentication.X509.ClientCAFile
+	}
+	return parseFlags(argsFileFlags(data), false)["--client-ca-file"]
+}
+
+func caPool(root string, path string) *x509.CertPool {
+	if path == "" {
+		return nil
+	}
+	data, err := os.ReadFile(filepath.Join(root, path))
+	if err != nil {
+		return nil
+	}
+	certs, _ := parsePEM(data)
+	if len(certs) == 0 {
+		return nil
+	}
+	pool := x509.NewCertPool()
+	for _, c := range certs {
+		pool.AddCert(c)
+	}
+	return pool
+}
+
+func inspectCertificate(ctx ProbeContext, path string, roots *x509.CertPool) (CertificateInfo, bool) {
+	data, err := os.ReadFile(filepath.Join(ctx.Root, path))
+	if err != nil {
+		return CertificateInfo{}, false
+	}
+	certs, key := parsePEM(data)
+	if len(certs) == 0 {
+		return CertificateInfo{}, false
+	}
+	cert := certs[0]
+	now := ctx.now()
+	info := CertificateInfo{
+		Path:               path,
+		Subject:            cert.Subject.String(),
+		Issuer:             cert.Issuer.String(),
+		SANs:               certificateSANs(cert),
+		NotBefore:          cert.NotBefore.UTC().Format(time.RFC3339),
+		NotAfter:           cert.NotAfter.UTC().Format(time.RFC3339),
+		DaysToExpiry:       daysToExpiry(cert, now),
+		Expired:            now.After(cert.NotAfter),
+		SignatureAlgorithm: cert.SignatureAlgorithm.String(),
+	}
+	info.KeyAlgorithm, info.KeySize = publicKeyInfo(cert.PublicKey)
+	if key == nil {
+		if keyData, err := os.ReadFile(filepath.Join(ctx.Root, strings.TrimSuffix(path, filepath.Ext(path))+".key")); err == nil {
+			_, key = parsePEM(keyData)
+		}
+	}
+	if key != nil {
+		info.KeyMatch = boolPtr(keyMatches(cert.PublicKey, key))
+	}
+	if roots != nil {
+		intermediates := x509.NewCertPool()
+		for _, c := range certs[1:] {
+			intermediates.AddCert(c)
+		}
+		// chain is verified at certificate issuance time, expiry is reported separately
+		_, err := cert.Verify(x509.VerifyOptions{
+			Roots:         roots,
+			Intermediates: intermediates,
+			CurrentTime:   cert.NotBefore.Add(time.Second),
+			KeyUsages:     []x509.ExtKeyUsage{x509.ExtKeyUsageAny},
+		})
+		info.ChainsToCA = boolPtr(err == nil)
+	}
+	info.Weaknesses = certificateWeaknesses(info, cert)
+	return info, true
+}
+
+// parsePEM certificates and first private key of PEM data
+func parsePEM(data []byte) ([]*x509.Certificate, crypto.PrivateKey) {
+	certs := make([]*x509.Certificate, 0)
+	var key crypto.PrivateKey
+	for {
+		var block *pem.Block
+		block, data = pem.Decode(data)
+		if block == nil {
+			break
+		}
+		switch {
+		case block.Type == "CERTIFICATE":
+			if c, err := x509.ParseCertificate(block.Bytes); err == nil {
+				certs = append(certs, c)
+			}
+		case strings.HasSuffix(block.Type, "PRIVATE KEY") && key == nil:
+			key = parsePrivateKey(block.Bytes)
+		}
+	}
+	return certs, key
+}
+
+func parsePrivateKey(der []byte) crypto.PrivateKey {
+	if key, err := x509.ParsePKCS8PrivateKey(der); err == nil {
+		return key
+	}
+	if key, err := x509.ParsePKCS1PrivateKey(der); err == nil {
+		return key
+	}
+	if key, err := x509.ParseECPrivateKey(der); err == nil {
+		return key
+	}
+	return nil
+}
+
+func keyMatches(pub crypto.PublicKey, key crypto.PrivateKey) bool {
+	signer, ok := key.(crypto.Signer)
+	if !ok {
+		return false
+	}
+	public, ok := signer.Public().(interface{ Equal(crypto.PublicKey) bool })
+	return ok && public.Equal(pub)
+}
+
+func publicKeyInfo(pub crypto.PublicKey) (string, int) {
+	switch k := pub.(type) {
+	case *rsa.PublicKey:
+		return "RSA", k.N.BitLen()
+	case *ecdsa.PublicKey:
+		return "ECDSA", k.Curve.Params().BitSize
+	case ed25519.PublicKey:
+		return "Ed25519", ed25519.PublicKeySize * 8
+	}
+	return "unknown", 0
+}
+
+func certificateSANs(cert *x509.Certificate) []string {
+	sans := make([]string, 0, len(cert.DNSNames)+len(cert.IPAddresses))
+	sans = append(sans, cert.DNSNames...)
+	for _, ip := range cert.IPAddresses {
+		sans = append(sans, ip.String())
+	}
+	for _, u := range cert.URIs {
+		sans = append(sans, u.String())
+	}
+	sans = append(sans, cert.EmailAddresses...)
+	return sans
+}
+
+// certificateWeaknesses expired certificate, short keys and weak signature algorithms
+func certificateWeaknesses(info CertificateInfo, cert *x509.Certificate) []string {
+	weaknesses := make([]string, 0)
+	if info.Expired {
+		weaknesses = append(weaknesses, "expired")
+	}
+	switch {
+	case info.KeyAlgorithm == "RSA" && info.KeySize < minRSAKeySize:
+		weaknesses = append(weaknesses, fmt.Sprintf("RSA key size %d is lower than %d", info.KeySize, minRSAKeySize))
+	case info.KeyAlgorithm == "ECDSA" && info.KeySize < minECDSAKeySize:
+		weaknesses = append(weaknesses, fmt.Sprintf("ECDSA key size %d is lower than %d", info.KeySize, minECDSAKeySize))
+	}
+	if weakSignatureAlgorithms[cert.SignatureAlgorithm] {
+		weaknesses = append(weaknesses, fmt.Sprintf("weak signature algorithm %s", cert.SignatureAlgorithm))
+	}
+	if info.KeyMatch != nil && !*info.KeyMatch {
+		weaknesses = append(weaknesses, "private key does not match certificate")
+	}
+	if len(weaknesses) == 0 {
+		return nil
+	}
+	return weaknesses
+}
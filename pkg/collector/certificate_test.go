@@ -0,0 +1,185 @@
+package collector
+
+import (
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/pem"
+	"math/big"
+	"net"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func writeCertificate(t *testing.T, path string, template *x509.Certificate, pub crypto.PublicKey, parent *x509.Certificate, signer crypto.Signer, key crypto.Signer) *x509.Certificate {
+	t.Helper()
+	if parent == nil {
+		parent = template
+	}
+	der, err := x509.CreateCertificate(rand.Reader, template, parent, pub, signer)
+	assert.NoError(t, err)
+	data := pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: der})
+	if key != nil {
+		keyDER, err := x509.MarshalPKCS8PrivateKey(key)
+		assert.NoError(t, err)
+		data = append(data, pem.EncodeToMemory(&pem.Block{Type: "PRIVATE KEY", Bytes: keyDER})...)
+	}
+	assert.NoError(t, os.MkdirAll(filepath.Dir(path), 0o755))
+	assert.NoError(t, os.WriteFile(path, data, 0o600))
+	cert, err := x509.ParseCertificate(der)
+	assert.NoError(t, err)
+	return cert
+}
+
+func TestCertificateProbe(t *testing.T) {
+	root := t.TempDir()
+	pki := filepath.Join(root, "/etc/kubernetes/pki")
+	now := time.Date(2024, 6, 1, 0, 0, 0, 0, time.UTC)
+
+	caKey, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	assert.NoError(t, err)
+	caTemplate := &x509.Certificate{
+		SerialNumber:          big.NewInt(1),
+		Subject:               pkix.Name{CommonName: "kubernetes"},
+		NotBefore:             now.AddDate(-1, 0, 0),
+		NotAfter:              now.AddDate(9, 0, 0),
+		IsCA:                  true,
+		BasicConstraintsValid: true,
+		KeyUsage:              x509.KeyUsageCertSign,
+	}
+	ca := writeCertificate(t, filepath.Join(pki, "ca.crt"), caTemplate, caKey.Public(), nil, caKey, nil)
+
+	serverKey, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	assert.NoError(t, err)
+	writeCertificate(t, filepath.Join(pki, "apiserver.crt"), &x509.Certificate{
+		SerialNumber: big.NewInt(2),
+		Subject:      pkix.Name{CommonName: "kube-apiserver"},
+		DNSNames:     []string{"kubernetes", "kubernetes.default"},
+		IPAddresses:  []net.IP{net.ParseIP("10.96.0.1")},
+		NotBefore:    now.AddDate(0, -1, 0),
+		NotAfter:     now.AddDate(0, 0, 30),
+		ExtKeyUsage:  []x509.ExtKeyUsage{x509.ExtKeyUsageServerAuth},
+	}, serverKey.Public(), ca, caKey, nil)
+	keyDER, err := x509.MarshalECPrivateKey(serverKey)
+	assert.NoError(t, err)
+	assert.NoError(t, os.WriteFile(filepath.Join(pki, "apiserver.key"), pem.EncodeToMemory(&pem.Block{Type: "EC PRIVATE KEY", Bytes: keyDER}), 0o600))
+
+	weakKey, err := rsa.GenerateKey(rand.Reader, 1024)
+	assert.NoError(t, err)
+	otherKey, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	assert.NoError(t, err)
+	writeCertificate(t, filepath.Join(pki, "front-proxy-client.crt"), &x509.Certificate{
+		SerialNumber: big.NewInt(3),
+		Subject:      pkix.Name{CommonName: "front-proxy-client"},
+		NotBefore:    now.AddDate(-2, 0, 0),
+		NotAfter:     now.AddDate(0, 0, -1),
+	}, weakKey.Public(), nil, weakKey, otherKey)
+	assert.NoError(t, os.WriteFile(filepath.Join(pki, "sa.crt"), []byte("not a certificate"), 0o600))
+
+	got, err := certificateProbe(ProbeContext{Root: root, Now: now}, "/etc/kubernetes/pki/*.crt,/etc/kubernetes/pki/missing.crt,ca=/etc/kubernetes/pki/ca.crt")
+	assert.NoError(t, err)
+	assert.Len(t, got, 3)
+
+	server := got[0].(CertificateInfo)
+	assert.Equal(t, "/etc/kubernetes/pki/apiserver.crt", server.Path)
+	assert.Equal(t, "CN=kube-apiserver", server.Subject)
+	assert.Equal(t, "CN=kubernetes", server.Issuer)
+	assert.Equal(t, []string{"kubernetes", "kubernetes.default", "10.96.0.1"}, server.SANs)
+	assert.Equal(t, "2024-07-01T00:00:00Z", server.NotAfter)
+	assert.Equal(t, 30, server.DaysToExpiry)
+	assert.False(t, server.Expired)
+	assert.Equal(t, "ECDSA", server.KeyAlgorithm)
+	assert.Equal(t, 256, server.KeySize)
+	assert.Equal(t, "ECDSA-SHA256", server.SignatureAlgorithm)
+	assert.Equal(t, boolPtr(true), server.KeyMatch)
+	assert.Equal(t, boolPtr(true), server.ChainsToCA)
+	assert.Nil(t, server.Weaknesses)
+
+	caInfo := got[1].(CertificateInfo)
+	assert.Equal(t, "/etc/kubernetes/pki/ca.crt", caInfo.Path)
+	assert.Nil(t, caInfo.KeyMatch)
+	assert.Equal(t, boolPtr(true), caInfo.ChainsToCA)
+
+	weak := got[2].(CertificateInfo)
+	assert.Equal(t, "/etc/kubernetes/pki/front-proxy-client.crt", weak.Path)
+	assert.True(t, weak.Expired)
+	assert.Equal(t, -1, weak.DaysToExpiry)
+	assert.Equal(t, "RSA", weak.KeyAlgorithm)
+	assert.Equal(t, 1024, weak.KeySize)
+	assert.Equal(t, boolPtr(false), weak.KeyMatch)
+	assert.Equal(t, boolPtr(false), weak.ChainsToCA)
+	assert.Equal(t, []string{"expired", "RSA key size 1024 is lower than 2048", "private key does not match certificate"}, weak.Weaknesses)
+
+	got, err = certificateProbe(ProbeContext{Root: root, Now: now}, "/etc/kubernetes/pki/apiserver.crt")
+	assert.NoError(t, err)
+	assert.Nil(t, got[0].(CertificateInfo).ChainsToCA)
+}
+
+func TestCertificateProbeKubeletCA(t *testing.T) {
+	now := time.Date(2024, 6, 1, 0, 0, 0, 0, time.UTC)
+	tests := []struct {
+		name   string
+		files  map[string]string
+		caPath string
+	}{
+		{
+			name:   "k3s kubelet config",
+			files:  map[string]string{"/var/lib/kubelet/config.yaml": "apiVersion: kubelet.config.k8s.io/v1beta1\nkind: KubeletConfiguration\nauthentication:\n  x509:\n    clientCAFile: /var/lib/rancher/k3s/agent/client-ca.crt\n"},
+			caPath: "/var/lib/rancher/k3s/agent/client-ca.crt",
+		},
+		{
+			name:   "kubelet args file",
+			files:  map[string]string{"/var/lib/kubelet/config.yaml": "--client-ca-file=/var/snap/microk8s/current/certs/ca.crt\n--anonymous-auth=false\n"},
+			caPath: "/var/snap/microk8s/current/certs/ca.crt",
+		},
+		{
+			name: "kubelet systemd unit flag",
+			files: map[string]string{
+				"/etc/systemd/system/kubelet.service": "[Service]\nExecStart=/usr/bin/kubelet --client-ca-file /etc/kubernetes/pki/kubelet-ca.crt\n",
+				"/var/lib/kubelet/config.yaml":        "authentication:\n  x509:\n    clientCAFile: /etc/kubernetes/pki/ca.crt\n",
+			},
+			caPath: "/etc/kubernetes/pki/kubelet-ca.crt",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			root := writeFiles(t, tt.files)
+			caKey, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+			assert.NoError(t, err)
+			ca := writeCertificate(t, filepath.Join(root, tt.caPath), &x509.Certificate{
+				SerialNumber:          big.NewInt(1),
+				Subject:               pkix.Name{CommonName: "kubelet-client-ca"},
+				NotBefore:             now.AddDate(-1, 0, 0),
+				NotAfter:              now.AddDate(9, 0, 0),
+				IsCA:                  true,
+				BasicConstraintsValid: true,
+				KeyUsage:              x509.KeyUsageCertSign,
+			}, caKey.Public(), nil, caKey, nil)
+			clientKey, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+			assert.NoError(t, err)
+			writeCertificate(t, filepath.Join(root, "/var/lib/kubelet/pki/kubelet-client.crt"), &x509.Certificate{
+				SerialNumber: big.NewInt(2),
+				Subject:      pkix.Name{CommonName: "system:node:node-1"},
+				NotBefore:    now.AddDate(0, -1, 0),
+				NotAfter:     now.AddDate(1, 0, 0),
+				ExtKeyUsage:  []x509.ExtKeyUsage{x509.ExtKeyUsageClientAuth},
+			}, clientKey.Public(), ca, caKey, nil)
+
+			ctx := ProbeContext{Root: root, Now: now, Params: map[string]string{"$kubelet.confs": "/var/lib/kubelet/config.yaml"}}
+			assert.Equal(t, tt.caPath, kubeletClientCAFile(ctx))
+			got, err := certificateProbe(ctx, "/var/lib/kubelet/pki/*.crt,ca=kubelet")
+			assert.NoError(t, err)
+			if assert.Len(t, got, 1) {
+				assert.Equal(t, boolPtr(true), got[0].(CertificateInfo).ChainsToCA)
+			}
+		})
+	}
+}
@@ -3,12 +3,15 @@ package collector
 import (
 	"context"
 	"fmt"
+	"log/slog"
 	"regexp"
 	"strings"
 
+	corev1 "k8s.io/api/core/v1"
 	"k8s.io/apimachinery/pkg/api/meta"
 	v1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/fields"
 	"k8s.io/apimachinery/pkg/runtime/schema"
 	"k8s.io/cli-runtime/pkg/genericclioptions"
 	"k8s.io/client-go/dynamic"
@@ -18,16 +21,67 @@ import (
 )
 
 const (
-	native   = "k8s"
-	gke      = "gke"
-	aks      = "aks"
-	eks      = "eks"
-	rke2     = "rke2"
-	k3s      = "k3s"
-	ocp      = "ocp"
-	microk8s = "microk8s"
+	native       = "k8s"
+	gke          = "gke"
+	aks          = "aks"
+	eks          = "eks"
+	rke2         = "rke2"
+	k3s          = "k3s"
+	ocp          = "ocp"
+	microk8s     = "microk8s"
+	kind         = "kind"
+	k0s          = "k0s"
+	talos        = "talos"
+	bottlerocket = "bottlerocket"
 )
 
+// platforms supported by commands platforms and version mapping
+var platforms = []string{native, gke, aks, eks, rke2, k3s, ocp, microk8s, kind, k0s, talos, bottlerocket}
+
+const (
+	evidenceLabel      = "label"
+	evidenceAnnotation = "annotation"
+	evidenceProviderID = "providerID"
+	evidenceOSImage    = "osImage"
+	evidenceVersion    = "version"
+)
+
+// platformRule platform evidence, a node label or annotation key (optionally key=value), a node providerID
+// or osImage prefix or a server version substring
+type platformRule struct {
+	platform string
+	source   string
+	value    string
+}
+
+// platformRules ordered by precedence, distributions first, then managed platforms labels and version, then os and
+// finally cloud provider ids, which are shared by self-managed clusters running on the same cloud
+var platformRules = []platformRule{
+	{platform: k3s, source: evidenceVersion, value: "+k3s"},
+	{platform: k3s, source: evidenceLabel, value: "node.kubernetes.io/instance-type=k3s"},
+	{platform: k3s, source: evidenceAnnotation, value: "k3s.io/node-args"},
+	{platform: rke2, source: evidenceVersion, value: "+rke2"},
+	{platform: rke2, source: evidenceLabel, value: "node.kubernetes.io/instance-type=rke2"},
+	{platform: rke2, source: evidenceAnnotation, value: "rke2.io/node-args"},
+	{platform: microk8s, source: evidenceVersion, value: "microk8s"},
+	{platform: microk8s, source: evidenceLabel, value: "microk8s.io/cluster"},
+	{platform: k0s, source: evidenceVersion, value: "+k0s"},
+	{platform: k0s, source: evidenceLabel, value: "node.k0sproject.io/role"},
+	{platform: talos, source: evidenceOSImage, value: "Talos"},
+	{platform: kind, source: evidenceProviderID, value: "kind://"},
+	{platform: eks, source: evidenceLabel, value: "eks.amazonaws.com/nodegroup"},
+	{platform: eks, source: evidenceLabel, value: "eks.amazonaws.com/compute-type"},
+	{platform: eks, source: evidenceLabel, value: "alpha.eksctl.io/cluster-name"},
+	{platform: eks, source: evidenceVersion, value: "-eks-"},
+	{platform: aks, source: evidenceLabel, value: "kubernetes.azure.com/cluster"},
+	{platform: gke, source: evidenceLabel, value: "cloud.google.com/gke-nodepool"},
+	{platform: gke, source: evidenceVersion, value: "-gke."},
+	{platform: bottlerocket, source: evidenceOSImage, value: "Bottlerocket"},
+	{platform: eks, source: evidenceProviderID, value: "aws://"},
+	{platform: aks, source: evidenceProviderID, value: "azure://"},
+	{platform: gke, source: evidenceProviderID, value: "gce://"},
+}
+
 type Cluster struct {
 	clientSet     *kubernetes.Clientset
 	cConfig       clientcmd.ClientConfig
@@ -38,6 +92,8 @@ type Cluster struct {
 type Platform struct {
 	Name    string
 	Version string
+	// Evidence node labels, annotations, providerID, osImage or version the platform was detected from
+	Evidence []string
 }
 
 func NewCluster(clientSet *kubernetes.Clientset, clientConfig clientcmd.ClientConfig, restMApper meta.RESTMapper, dynamicClient dynamic.Interface) *Cluster {
@@ -70,35 +126,98 @@ func GetCluster() (*Cluster, error) {
 	return NewCluster(clientset, clientConfig, restMapper, k8sDynamicClient), nil
 }
 
+// Platfrom detect cluster platform from the server version and the first listed node
+//
+// Deprecated: use NodePlatform with the local node name
 func (cluster *Cluster) Platfrom() (Platform, error) {
-	v := cluster.getOpenShiftVersion(context.Background())
+	var nodeName string
+	nodes, err := cluster.clientSet.CoreV1().Nodes().List(context.Background(), v1.ListOptions{Limit: 1})
+	if err == nil && len(nodes.Items) > 0 {
+		nodeName = nodes.Items[0].Name
+	}
+	return cluster.NodePlatform(context.Background(), nodeName)
+}
+
+// NodePlatform detect cluster platform from the local node object providerID, labels, annotations and osImage,
+// and from the server version, the node is fetched only when nodeName is set. when the node can not be fetched,
+// example: get nodes is forbidden, the platform is detected from the server version only
+func (cluster *Cluster) NodePlatform(ctx context.Context, nodeName string) (Platform, error) {
+	v := cluster.getOpenShiftVersion(ctx)
 	if len(v) != 0 {
-		return Platform{Name: "ocp", Version: majorVersion(v)}, nil
+		return Platform{Name: ocp, Version: majorVersion(v), Evidence: []string{fmt.Sprintf("clusterversion %s", v)}}, nil
 	}
-	nodeName := cluster.getNodeName()
 	semVersion, err := cluster.clientSet.ServerVersion()
 	if err != nil {
 		return Platform{}, err
 	}
-	p := getPlatformInfoFromVersion(semVersion.GitVersion)
+	var node *corev1.Node
+	if nodeName != "" {
+		node, err = cluster.getNode(ctx, nodeName)
+		if err != nil {
+			slog.Debug("failed to get node, detecting platform from server version", "node", nodeName, "error", err)
+			node = nil
+		}
+	}
+	name, evidence := detectPlatform(node, semVersion.GitVersion)
+	return Platform{Name: name, Version: getPlatformInfoFromVersion(semVersion.GitVersion).Version, Evidence: evidence}, nil
+}
+
+// detectPlatform platform of the first matching rule and every matching rule of that platform as evidence,
+// default to native k8s, node can be nil
+func detectPlatform(node *corev1.Node, gitVersion string) (string, []string) {
 	var name string
-	switch {
-	case strings.Contains(p.Version, k3s):
-		name = k3s
-	case strings.Contains(p.Version, rke2):
-		name = rke2
-	case strings.Contains(p.Version, microk8s):
-		name = microk8s
-	case strings.Contains(nodeName, aks):
-		name = aks
-	case strings.Contains(nodeName, eks):
-		name = eks
-	case strings.Contains(nodeName, gke):
-		name = gke
-	default:
-		name = "k8s"
-	}
-	return Platform{Name: name, Version: p.Version}, nil
+	evidence := make([]string, 0)
+	for _, rule := range platformRules {
+		if name != "" && rule.platform != name {
+			continue
+		}
+		if e := rule.match(node, gitVersion); e != "" {
+			name = rule.platform
+			evidence = append(evidence, e)
+		}
+	}
+	if name == "" {
+		return native, evidence
+	}
+	return name, evidence
+}
+
+// match return the rule evidence description when node or version match the rule, empty otherwise
+func (r platformRule) match(node *corev1.Node, gitVersion string) string {
+	if r.source == evidenceVersion {
+		if strings.Contains(gitVersion, r.value) {
+			return fmt.Sprintf("%s %s", r.source, gitVersion)
+		}
+		return ""
+	}
+	if node == nil {
+		return ""
+	}
+	switch r.source {
+	case evidenceLabel:
+		return matchMetadata(r.source, node.Labels, r.value)
+	case evidenceAnnotation:
+		return matchMetadata(r.source, node.Annotations, r.value)
+	case evidenceProviderID:
+		if strings.HasPrefix(node.Spec.ProviderID, r.value) {
+			return fmt.Sprintf("%s %s", r.source, node.Spec.ProviderID)
+		}
+	case evidenceOSImage:
+		if strings.HasPrefix(node.Status.NodeInfo.OSImage, r.value) {
+			return fmt.Sprintf("%s %s", r.source, node.Status.NodeInfo.OSImage)
+		}
+	}
+	return ""
+}
+
+// matchMetadata match key or key=value against node labels or annotations
+func matchMetadata(source string, metadata map[string]string, keyValue string) string {
+	key, value, withValue := strings.Cut(keyValue, "=")
+	v, ok := metadata[key]
+	if !ok || (withValue && v != value) {
+		return ""
+	}
+	return fmt.Sprintf("%s %s=%s", source, key, v)
 }
 
 func getPlatformInfoFromVersion(s string) Platform {
@@ -133,18 +252,26 @@ func (cluster *Cluster) getOpenShiftVersion(ctx context.Context) string {
 	}
 	return version
 }
-func (cluster *Cluster) getNodeName() string {
-	nodes, err := cluster.clientSet.CoreV1().Nodes().List(context.Background(), v1.ListOptions{})
+
+// getNode fetch node by name with a field selector, avoid listing every cluster nodes
+func (cluster *Cluster) getNode(ctx context.Context, nodeName string) (*corev1.Node, error) {
+	nodes, err := cluster.clientSet.CoreV1().Nodes().List(ctx, v1.ListOptions{
+		FieldSelector: fields.OneTermEqualSelector("metadata.name", nodeName).String(),
+	})
 	if err != nil {
-		return "k8s"
+		return nil, err
+	}
+	if len(nodes.Items) == 0 {
+		return nil, fmt.Errorf("node %s not found", nodeName)
 	}
-	return nodes.Items[0].Name
+	return &nodes.Items[0], nil
 }
 
 func (cluster *Cluster) getDynamicClient(gvr schema.GroupVersionResource) dynamic.ResourceInterface {
 	return cluster.dynamicClient.Resource(gvr).Namespace("")
 }
 
+// majorVersion major.minor of semantic version, example: v1.23.2 => 1.23, empty when version can not be parsed
 func majorVersion(semanticVersion string) string {
 	versionRe := regexp.MustCompile(`v(\d+\.\d+)\.\d+`)
 	version := semanticVersion
@@ -152,5 +279,8 @@ func majorVersion(semanticVersion string) string {
 		version = fmt.Sprintf("v%s", semanticVersion)
 	}
 	subs := versionRe.FindStringSubmatch(version)
+	if len(subs) < 2 {
+		return ""
+	}
 	return subs[1]
 }
@@ -0,0 +1,106 @@
+package collector
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	corev1 "k8s.io/api/core/v1"
+	v1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+func TestDetectPlatform(t *testing.T) {
+	tests := []struct {
+		name         string
+		node         *corev1.Node
+		version      string
+		wantPlatform string
+		wantEvidence []string
+	}{
+		{
+			name: "aks providerID and label",
+			node: &corev1.Node{
+				ObjectMeta: v1.ObjectMeta{Labels: map[string]string{"kubernetes.azure.com/cluster": "MC_rg_aks_westeurope"}},
+				Spec:       corev1.NodeSpec{ProviderID: "azure:///subscriptions/1234/resourceGroups/mc_rg/providers/Microsoft.Compute/virtualMachineScaleSets/aks-nodepool1/virtualMachines/0"},
+			},
+			version:      "v1.28.5",
+			wantPlatform: aks,
+			wantEvidence: []string{"label kubernetes.azure.com/cluster=MC_rg_aks_westeurope", "providerID azure:///subscriptions/1234/resourceGroups/mc_rg/providers/Microsoft.Compute/virtualMachineScaleSets/aks-nodepool1/virtualMachines/0"},
+		},
+		{
+			name:         "eks version and providerID",
+			node:         &corev1.Node{Spec: corev1.NodeSpec{ProviderID: "aws:///us-east-1a/i-0123456789abcdef0"}},
+			version:      "v1.23.17-eks-8ccc7ba",
+			wantPlatform: eks,
+			wantEvidence: []string{"version v1.23.17-eks-8ccc7ba", "providerID aws:///us-east-1a/i-0123456789abcdef0"},
+		},
+		{
+			name:         "gke providerID",
+			node:         &corev1.Node{Spec: corev1.NodeSpec{ProviderID: "gce://project/us-central1-a/gke-cluster-default-pool-1234"}},
+			version:      "v1.27.3",
+			wantPlatform: gke,
+			wantEvidence: []string{"providerID gce://project/us-central1-a/gke-cluster-default-pool-1234"},
+		},
+		{
+			name: "rke2 on aws",
+			node: &corev1.Node{
+				ObjectMeta: v1.ObjectMeta{Labels: map[string]string{"node.kubernetes.io/instance-type": "rke2"}},
+				Spec:       corev1.NodeSpec{ProviderID: "aws:///us-east-1a/i-0123456789abcdef0"},
+			},
+			version:      "v1.23.11+rke2r1",
+			wantPlatform: rke2,
+			wantEvidence: []string{"version v1.23.11+rke2r1", "label node.kubernetes.io/instance-type=rke2"},
+		},
+		{
+			name:         "kind",
+			node:         &corev1.Node{Spec: corev1.NodeSpec{ProviderID: "kind://docker/kind/kind-control-plane"}},
+			version:      "v1.30.0",
+			wantPlatform: kind,
+			wantEvidence: []string{"providerID kind://docker/kind/kind-control-plane"},
+		},
+		{
+			name:         "k0s version",
+			version:      "v1.28.4+k0s",
+			wantPlatform: k0s,
+			wantEvidence: []string{"version v1.28.4+k0s"},
+		},
+		{
+			name:         "talos os image",
+			node:         &corev1.Node{Status: corev1.NodeStatus{NodeInfo: corev1.NodeSystemInfo{OSImage: "Talos (v1.6.4)"}}},
+			version:      "v1.29.1",
+			wantPlatform: talos,
+			wantEvidence: []string{"osImage Talos (v1.6.4)"},
+		},
+		{
+			name: "bottlerocket on eks managed node group",
+			node: &corev1.Node{
+				ObjectMeta: v1.ObjectMeta{Labels: map[string]string{"eks.amazonaws.com/nodegroup": "ng-1"}},
+				Spec:       corev1.NodeSpec{ProviderID: "aws:///us-east-1a/i-0123456789abcdef0"},
+				Status:     corev1.NodeStatus{NodeInfo: corev1.NodeSystemInfo{OSImage: "Bottlerocket OS 1.19.2 (aws-k8s-1.29)"}},
+			},
+			version:      "v1.29.1",
+			wantPlatform: eks,
+			wantEvidence: []string{"label eks.amazonaws.com/nodegroup=ng-1", "providerID aws:///us-east-1a/i-0123456789abcdef0"},
+		},
+		{
+			name:         "self managed bottlerocket",
+			node:         &corev1.Node{Status: corev1.NodeStatus{NodeInfo: corev1.NodeSystemInfo{OSImage: "Bottlerocket OS 1.19.2 (vmware-k8s-1.29)"}}},
+			version:      "v1.29.1",
+			wantPlatform: bottlerocket,
+			wantEvidence: []string{"osImage Bottlerocket OS 1.19.2 (vmware-k8s-1.29)"},
+		},
+		{
+			name:         "native k8s node name containing managed platform name",
+			node:         &corev1.Node{ObjectMeta: v1.ObjectMeta{Name: "worker-eks-1"}},
+			version:      "v1.29.1",
+			wantPlatform: native,
+			wantEvidence: []string{},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			gotPlatform, gotEvidence := detectPlatform(tt.node, tt.version)
+			assert.Equal(t, tt.wantPlatform, gotPlatform)
+			assert.Equal(t, tt.wantEvidence, gotEvidence)
+		})
+	}
+}
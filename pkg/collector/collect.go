@@ -9,8 +9,9 @@ import (
 	"time"
 
 	"fmt"
-	"log"
+	"log/slog"
 	"path/filepath"
+	"sort"
 
 	"strconv"
 
@@ -22,105 +23,373 @@ import (
 const (
 	// Version is the version of the output
 	defaultSpec = "k8s-cis-1.23.0"
+
+	// DefaultHostRoot host root file system seen through the host init process, the collector own root when
+	// the pod does not share the host pid namespace
+	DefaultHostRoot = "/proc/1/root"
 )
 
 // CollectData run spec audit command and output it result data
 func CollectData(cmd *cobra.Command) error {
-	log.SetFlags(log.LstdFlags | log.Lmicroseconds)
-	cluster, err := GetCluster()
-	if err != nil {
-		return err
-	}
+	dryRun := cmd.Flag("dry-run").Value.String() == "true"
 	ctx, cancel := context.WithTimeout(cmd.Context(), time.Duration(10)*time.Minute)
 	defer cancel()
 
+	var err error
 	defer func() {
 		if errors.Is(err, context.DeadlineExceeded) {
-			log.Println("Increase --timeout value")
+			slog.Warn("collection timed out, increase --timeout value")
 		}
 	}()
-	shellCmd := NewShellCmd()
-	nodeType, err := shellCmd.FindNodeType()
+	nc, err := newNodeCollector(ctx, cmd, dryRun)
 	if err != nil {
 		return err
 	}
-	nodeFileconfig := cmd.Flag("node-config").Value.String()
-	lp, err := LoadConfigParams(nodeFileconfig)
+	outputFormat := cmd.Flag("output").Value.String()
+	if dryRun {
+		commands := filterCommands(nc.commands, nc.roles, nc.platform.Name)
+		return printDryRun(DryRun{NodeType: nodeType(nc.roles), Roles: nc.roles, Platform: nc.platform.Name, PlatformEvidence: nc.platform.Evidence, Commands: commands}, outputFormat, os.Stdout)
+	}
+	nodeData, err := nc.collect(ctx, nil)
 	if err != nil {
 		return err
 	}
-	cm := configParams(lp, shellCmd)
-	nodeCommands := cmd.Flag("node-commands").Value.String()
-	commands, err := GetNodesCommands(nodeCommands, cm, nodeType)
-	if len(commands) == 0 {
-		return fmt.Errorf("spec not found")
+	err = printOutput(nodeData, outputFormat, os.Stdout)
+	if err != nil {
+		return err
+	}
+	return nil
+}
+
+// nodeCollector payloads read once, payloads read from stdin can only be read once, and node roles, platform,
+// config params and commands resolved from them
+type nodeCollector struct {
+	cluster        *Cluster
+	nodeName       string
+	nodeType       string
+	hostRoot       string
+	hostFacts      bool
+	shell          Shell
+	nodeConfig     []byte
+	nodeCommands   []byte
+	loadSpec       func(platform Platform) ([]byte, error)
+	roles          []string
+	platform       Platform
+	params         map[string]string
+	commands       []Command
+	kubeletConfig  []byte
+	kubeletMapping []byte
+	redactor       *redactor
+	cache          *resultCache
+}
+
+// newNodeCollector read payloads and resolve node roles, platform and commands to execute, cluster access is optional:
+// without cluster node roles and platform are detected from the node and kubelet config is only read from --kubelet-config
+func newNodeCollector(ctx context.Context, cmd *cobra.Command, dryRun bool) (*nodeCollector, error) {
+	cluster, err := GetCluster()
+	if err != nil {
+		slog.Info("cluster not reachable, detecting node roles and platform from the node", "error", err)
+		cluster = nil
+	}
+	nc := &nodeCollector{
+		cluster:   cluster,
+		nodeName:  cmd.Flag("node").Value.String(),
+		nodeType:  cmd.Flag("node-type").Value.String(),
+		hostRoot:  hostRoot(cmd),
+		hostFacts: cmd.Flag("host-facts").Value.String() == "true",
+		shell:     NewShellCmd(),
+	}
+	payloads, err := newPayloadLoader(cmd)
+	if err != nil {
+		return nil, err
+	}
+	nc.nodeConfig, err = payloadOrDefault(payloads, cmd, "node-config", func() ([]byte, error) {
+		return defaultNodeConfig, nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	nc.nodeCommands, err = payloads.load(cmd, "node-commands")
+	if err != nil {
+		return nil, err
+	}
+	if len(nc.nodeCommands) == 0 {
+		mapper, err := versionMapper(payloads, cmd, nc.nodeConfig)
+		if err != nil {
+			return nil, err
+		}
+		nc.loadSpec = func(platform Platform) ([]byte, error) {
+			return loadNodeSpec(cmd, platform, mapper)
+		}
+	}
+	if err := nc.resolve(ctx); err != nil {
+		return nil, err
+	}
+	if dryRun {
+		return nc, nil
+	}
+	nc.kubeletConfig, err = payloads.read(cmd, "kubelet-config")
+	if err != nil {
+		return nil, err
+	}
+	if (nc.nodeName != "" && cluster != nil) || len(nc.kubeletConfig) != 0 {
+		nc.kubeletMapping, err = payloadOrDefault(payloads, cmd, "kubelet-config-mapping", func() ([]byte, error) {
+			return defaultKubeletConfigMapping, nil
+		})
+		if err != nil {
+			return nil, err
+		}
 	}
-	nodeInfo, err := ExecuteCommands(shellCmd, commands)
+	redactRules, err := cmd.Flags().GetStringSlice("redact")
+	if err != nil {
+		return nil, err
+	}
+	nc.redactor, err = newRedactor(redactRules)
+	if err != nil {
+		return nil, err
+	}
+	if cacheDir := cmd.Flag("cache-dir").Value.String(); cacheDir != "" {
+		nc.cache, err = loadResultCache(cacheDir)
+		if err != nil {
+			return nil, err
+		}
+	}
+	return nc, nil
+}
+
+// hostRoot --host-root flag value, the default host root fall back to / when it can not be read, example:
+// hostPID pod without SYS_PTRACE capability or collector not running as root
+func hostRoot(cmd *cobra.Command) string {
+	root := cmd.Flag("host-root").Value.String()
+	if root != DefaultHostRoot || cmd.Flag("host-root").Changed {
+		return root
+	}
+	if _, err := os.ReadDir(root); err != nil {
+		slog.Warn("host root not readable, reading host files from /", "path", root, "error", err)
+		return "/"
+	}
+	return root
+}
+
+// resolve node roles, config params, platform and commands to execute, long running collectors resolve them
+// on every collection to pick up config paths created and roles changed after start
+func (nc *nodeCollector) resolve(ctx context.Context) error {
+	roles, err := NodeRoles(ctx, nc.shell, nc.cluster, nc.nodeName, nc.nodeType)
+	if err != nil {
+		return err
+	}
+	lp, err := parseConfigParams(nc.nodeConfig)
 	if err != nil {
 		return err
 	}
-	nodeName := cmd.Flag("node").Value.String()
-	kubeletConfig := cmd.Flag("kubelet-config").Value.String()
-	if nodeName != "" || kubeletConfig != "" {
-		nodeConfig, err := loadNodeConfig(ctx, *cluster, nodeName, kubeletConfig)
+	params := configParams(lp, nc.shell)
+	// platform lookup may cost an api server round-trip, it is only resolved to select the built-in spec
+	// or when spec commands are restricted to platforms for the dry run listing
+	var platform Platform
+	platformResolved := false
+	resolvePlatform := func() {
+		if platformResolved {
+			return
+		}
+		platformResolved = true
+		platform = clusterPlatform(ctx, nc.cluster, nc.nodeName)
+		if platform.Name == "" {
+			platform = localPlatform(nc.hostRoot, nc.shell)
+		}
+	}
+	nodeCommands := nc.nodeCommands
+	if len(nodeCommands) == 0 {
+		resolvePlatform()
+		nodeCommands, err = nc.loadSpec(platform)
+		if err != nil {
+			return err
+		}
+	}
+	specCommands, err := parseNodeCommands(nodeCommands, params)
+	if err != nil {
+		return err
+	}
+	if len(specCommands) == 0 {
+		return fmt.Errorf("spec not found")
+	}
+	if hasPlatforms(specCommands) {
+		resolvePlatform()
+	}
+	nc.roles = roles
+	nc.params = params
+	nc.platform = platform
+	nc.commands = specCommands
+	return nil
+}
+
+// collect execute commands, merge kubelet config values and return the redacted node document
+func (nc *nodeCollector) collect(ctx context.Context, observe commandObserver) (Node, error) {
+	nodeInfo, err := nc.executeCommands(observe)
+	if err != nil {
+		return Node{}, err
+	}
+	if len(nc.kubeletMapping) != 0 {
+		nodeConfig, err := loadNodeConfig(ctx, nc.cluster, nc.nodeName, nc.kubeletConfig)
 		if err == nil {
-			kubeletConfigMapping := cmd.Flag("kubelet-config-mapping").Value.String()
-			mapping, err := LoadKubeletMapping(kubeletConfigMapping)
+			mapping, err := parseKubeletMapping(nc.kubeletMapping)
 			if err != nil {
-				return err
+				return Node{}, err
 			}
 			configVal := getValuesFromkubeletConfig(nodeConfig, mapping)
+			nc.redactor.redactInfo(configVal)
 			mergeConfigValues(nodeInfo, configVal)
 		}
 	}
-	nodeData := Node{
+	node := Node{
 		APIVersion: Version,
 		Kind:       Kind,
-		Type:       nodeType,
+		Type:       nodeType(nc.roles),
+		Roles:      nc.roles,
 		Metadata:   map[string]string{"creationTimestamp": time.Now().Format(time.RFC3339)},
 		Info:       nodeInfo,
 	}
-	outputFormat := cmd.Flag("output").Value.String()
-	err = printOutput(nodeData, outputFormat, os.Stdout)
-	if err != nil {
-		return err
+	if nc.hostFacts {
+		node.Facts = CollectHostFacts(nc.hostRoot)
 	}
-	return nil
+	return node, nil
 }
 
-func GetNodesCommands(nodeCommands string, configMap map[string]string, nodeType string) ([]Command, error) {
-	var commands []Command
-	var specInfo SpecInfo
-	if nodeCommands != "" {
-		fContent, err := uncompressAndDecode(nodeCommands)
+// executeCommands execute commands and return their redacted results, when the result cache is enabled only
+// commands which referenced files changed are executed, cache hits are observed as successful executions
+func (nc *nodeCollector) executeCommands(observe commandObserver) (map[string]*Info, error) {
+	probeCtx := ProbeContext{Root: nc.hostRoot, Params: nc.params}
+	if nc.cache == nil {
+		nodeInfo, err := executeCommands(nc.shell, probeCtx, nc.commands, observe)
 		if err != nil {
-			fmt.Println("failed to read node commands")
 			return nil, err
 		}
-		updatedContent := string(fContent)
-		for k, v := range configMap {
-			updatedContent = strings.ReplaceAll(updatedContent, k, v)
+		nc.redactor.redactInfo(nodeInfo)
+		return nodeInfo, nil
+	}
+	cached, misses, fingerprints := nc.cache.lookup(nc.hostRoot, nc.params, nc.commands)
+	if observe != nil {
+		for key := range cached {
+			observe(key, 0, false)
 		}
-		err = yaml.Unmarshal([]byte(updatedContent), &specInfo)
-		if err != nil {
-			return nil, err
+	}
+	nodeInfo, err := executeCommands(nc.shell, probeCtx, misses, observe)
+	if err != nil {
+		return nil, err
+	}
+	nc.redactor.redactInfo(nodeInfo)
+	nc.cache.store(misses, fingerprints, nodeInfo)
+	if err := nc.cache.save(); err != nil {
+		slog.Warn("failed to save result cache", "error", err)
+	}
+	return mergeConfigValues(nodeInfo, cached), nil
+}
+
+// GetNodesCommands decode node commands and substitute config params, commands of every node type are returned as
+// real runs execute all of them, node type is kept for compatibility
+func GetNodesCommands(nodeCommands string, configMap map[string]string, nodeType string) ([]Command, error) {
+	if nodeCommands == "" {
+		return nil, nil
+	}
+	fContent, err := uncompressAndDecode(nodeCommands)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read node commands: %w", err)
+	}
+	commands, err := parseNodeCommands(fContent, configMap)
+	if err != nil {
+		return nil, err
+	}
+	return commands, nil
+}
+
+// parseNodeCommands parse spec commands with variables substituted by config params values
+func parseNodeCommands(nodeCommands []byte, configMap map[string]string) ([]Command, error) {
+	if len(nodeCommands) == 0 {
+		return nil, nil
+	}
+	updatedContent := string(nodeCommands)
+	for k, v := range configMap {
+		updatedContent = strings.ReplaceAll(updatedContent, k, v)
+	}
+	var specInfo SpecInfo
+	err := yaml.Unmarshal([]byte(updatedContent), &specInfo)
+	if err != nil {
+		return nil, err
+	}
+	return specInfo.Commands, nil
+}
+
+// filterCommands dry run commands of node roles and platform, commands node type is a comma separated
+// list of roles, commands without platforms apply to every platform, empty platform skip platform filtering
+func filterCommands(commands []Command, roles []string, platform string) []Command {
+	filtered := make([]Command, 0, len(commands))
+	for _, c := range commands {
+		if !matchRoles(c.NodeType, roles) {
+			continue
 		}
-		for _, c := range specInfo.Commands {
-			if nodeType == "master" {
-				commands = append(commands, c)
-			} else if c.NodeType == nodeType {
-				commands = append(commands, c)
-			}
+		if platform != "" && len(c.Platforms) > 0 && !contains(c.Platforms, platform) {
+			continue
 		}
-		commands = specInfo.Commands
+		filtered = append(filtered, c)
 	}
-	return commands, nil
+	return filtered
+}
+
+// hasPlatforms whether any command is restricted to platforms
+func hasPlatforms(commands []Command) bool {
+	for _, c := range commands {
+		if len(c.Platforms) > 0 {
+			return true
+		}
+	}
+	return false
+}
+
+// clusterPlatform cluster platform name and version, empty platform when cluster is not available
+func clusterPlatform(ctx context.Context, cluster *Cluster, nodeName string) Platform {
+	if cluster == nil {
+		return Platform{}
+	}
+	platform, err := cluster.NodePlatform(ctx, nodeName)
+	if err != nil {
+		return Platform{}
+	}
+	return platform
 }
 
-func ExecuteCommands(shellCmd Shell, ci []Command) (map[string]*Info, error) {
+// ExecuteCommands execute commands audit with shell or with their probe and return values per command key
+func ExecuteCommands(shellCmd Shell, probeCtx ProbeContext, ci []Command) (map[string]*Info, error) {
+	return executeCommands(shellCmd, probeCtx, ci, nil)
+}
+
+// commandObserver notified of each command execution duration and whether it failed
+type commandObserver func(key string, duration time.Duration, failed bool)
+
+func executeCommands(shellCmd Shell, probeCtx ProbeContext, ci []Command, observe commandObserver) (map[string]*Info, error) {
 	nodeInfo := make(map[string]*Info)
 	for _, c := range ci {
-		output, err := shellCmd.Execute(c.Audit)
+		start := time.Now()
+		if c.Probe != "" {
+			values, err := runProbe(probeCtx, c)
+			duration := time.Since(start)
+			slog.Debug("probe executed", "key", c.Key, "probe", c.Probe, "arg", c.Audit, "duration", duration, "values", len(values), "error", err)
+			if observe != nil {
+				observe(c.Key, duration, err != nil)
+			}
+			if err != nil {
+				// a failing probe do not fail the other commands, the error is reported on its key
+				slog.Warn("probe failed", "key", c.Key, "probe", c.Probe, "error", err)
+				nodeInfo[c.Key] = &Info{Values: []interface{}{}, Error: err.Error()}
+				continue
+			}
+			nodeInfo[c.Key] = &Info{Values: values}
+			continue
+		}
+		output, exitCode, err := shellCmd.Run(c.Audit)
+		duration := time.Since(start)
+		slog.Debug("command executed", "key", c.Key, "command", c.Audit, "duration", duration, "exitCode", exitCode, "outputSize", len(output), "error", err)
+		if observe != nil {
+			observe(c.Key, duration, err != nil || exitCode != 0)
+		}
 		if err != nil {
 			return nil, err
 		}
@@ -130,11 +399,12 @@ func ExecuteCommands(shellCmd Shell, ci []Command) (map[string]*Info, error) {
 	return nodeInfo, nil
 }
 
-func loadNodeConfig(ctx context.Context, cluster Cluster, nodeName string, kubeletConfig string) (map[string]interface{}, error) {
+// loadNodeConfig kubelet config from kubeletConfig payload or from the node configz api, cluster is only used without payload
+func loadNodeConfig(ctx context.Context, cluster *Cluster, nodeName string, kubeletConfig []byte) (map[string]interface{}, error) {
 	var data []byte
 	var err error
-	if kubeletConfig != "" {
-		data, err = uncompressAndDecode(kubeletConfig)
+	if len(kubeletConfig) != 0 {
+		data = kubeletConfig
 	} else {
 		data, err = cluster.clientSet.RESTClient().Get().AbsPath(fmt.Sprintf("/api/v1/nodes/%s/proxy/configz", nodeName)).DoRaw(ctx)
 	}
@@ -149,6 +419,70 @@ func loadNodeConfig(ctx context.Context, cluster Cluster, nodeName string, kubel
 	return nodeConfig, nil
 }
 
+// versionMapper platform version to spec mapping from spec-version-mapping flag, node config or built-in node config
+func versionMapper(payloads *payloadLoader, cmd *cobra.Command, nodeFileconfig []byte) (Mapper, error) {
+	var mapper Mapper
+	data, err := payloads.read(cmd, "spec-version-mapping")
+	if err != nil {
+		return mapper, err
+	}
+	if len(data) == 0 {
+		data = nodeFileconfig
+	}
+	if err := yaml.Unmarshal(data, &mapper); err != nil {
+		return mapper, err
+	}
+	if len(mapper.VersionMapping) == 0 {
+		if err := yaml.Unmarshal(defaultNodeConfig, &mapper); err != nil {
+			return mapper, err
+		}
+	}
+	return mapper, nil
+}
+
+// loadNodeSpec load embedded spec from spec-name and spec-version flags, or matching platform, version and
+// spec-name flag in version mapping, cluster-version flag override platform version. default to basic k8s spec
+// when the matching spec is not embedded
+func loadNodeSpec(cmd *cobra.Command, platform Platform, mapper Mapper) ([]byte, error) {
+	name := cmd.Flag("spec-name").Value.String()
+	version := cmd.Flag("spec-version").Value.String()
+	if name != "" && version != "" {
+		return loadSpec(specName(name, version))
+	}
+	if clusterVersion := cmd.Flag("cluster-version").Value.String(); clusterVersion != "" {
+		platform.Version = majorVersion(clusterVersion)
+	}
+	versionMapping := mapper.VersionMapping
+	if name != "" {
+		versionMapping = map[string][]SpecVersion{platform.Name: specVersionsByName(mapper.VersionMapping, name)}
+	}
+	spec := specByPlatfromVersion(platform, versionMapping)
+	data, err := loadSpec(spec)
+	if err != nil {
+		slog.Info("spec not found, using default spec", "error", err, "spec", defaultSpec)
+		return loadSpec(defaultSpec)
+	}
+	return data, nil
+}
+
+// specVersionsByName version mapping entries of spec name across platforms, sorted by platform
+func specVersionsByName(versionMapping map[string][]SpecVersion, name string) []SpecVersion {
+	platformNames := make([]string, 0, len(versionMapping))
+	for p := range versionMapping {
+		platformNames = append(platformNames, p)
+	}
+	sort.Strings(platformNames)
+	versions := make([]SpecVersion, 0)
+	for _, p := range platformNames {
+		for _, v := range versionMapping[p] {
+			if v.CisSpecName == name {
+				versions = append(versions, v)
+			}
+		}
+	}
+	return versions
+}
+
 func specByPlatfromVersion(platfrom Platform, versionSpecMapper map[string][]SpecVersion) string {
 	speVersions, ok := versionSpecMapper[platfrom.Name]
 	if ok {
@@ -2,6 +2,7 @@ package collector
 
 import (
 	"bytes"
+	"context"
 	"encoding/base64"
 	"encoding/json"
 	"os"
@@ -232,6 +233,101 @@ func TestNodeCommamnd(t *testing.T) {
 	}
 }
 
+func TestFilterCommands(t *testing.T) {
+	commands := []Command{
+		{Key: "kubeAPIServerSpecFilePermission", NodeType: MasterNode},
+		{Key: "kubeletConfFilePermissions", NodeType: WorkerNode},
+		{Key: "kubeletServiceFilePermissions", NodeType: WorkerNode, Platforms: []string{native, rke2}},
+		{Key: "k3sServerDataDirPermissions", NodeType: MasterNode, Platforms: []string{k3s}},
+		{Key: "etcdDataDirectoryPermissions", NodeType: "master, etcd"},
+	}
+	tests := []struct {
+		name     string
+		roles    []string
+		platform string
+		want     []string
+	}{
+		{
+			name:  "master node no platform",
+			roles: []string{MasterNode, WorkerNode},
+			want:  []string{"kubeAPIServerSpecFilePermission", "kubeletConfFilePermissions", "kubeletServiceFilePermissions", "k3sServerDataDirPermissions", "etcdDataDirectoryPermissions"},
+		},
+		{
+			name:  "worker node no platform",
+			roles: []string{WorkerNode},
+			want:  []string{"kubeletConfFilePermissions", "kubeletServiceFilePermissions"},
+		},
+		{
+			name:  "etcd node",
+			roles: []string{EtcdNode, WorkerNode},
+			want:  []string{"kubeletConfFilePermissions", "kubeletServiceFilePermissions", "etcdDataDirectoryPermissions"},
+		},
+		{
+			name:     "master node k3s platform",
+			roles:    []string{MasterNode, WorkerNode},
+			platform: k3s,
+			want:     []string{"kubeAPIServerSpecFilePermission", "kubeletConfFilePermissions", "k3sServerDataDirPermissions", "etcdDataDirectoryPermissions"},
+		},
+		{
+			name:     "worker node gke platform",
+			roles:    []string{WorkerNode},
+			platform: gke,
+			want:     []string{"kubeletConfFilePermissions"},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := make([]string, 0)
+			for _, c := range filterCommands(commands, tt.roles, tt.platform) {
+				got = append(got, c.Key)
+			}
+			assert.Equal(t, tt.want, got)
+		})
+	}
+}
+
+func TestNodeCollectorResolve(t *testing.T) {
+	shell := fakeShell{}
+	nc := &nodeCollector{
+		shell: shell,
+		nodeConfig: []byte(`node:
+  apiserver:
+    confs:
+      - /etc/kubernetes/manifests/kube-apiserver.yaml
+  kubelet:
+    confs:
+      - /etc/kubernetes/kubelet/config.yaml
+      - /var/lib/kubelet/config.yaml
+    defaultconf: /var/lib/kubelet/config.yaml
+`),
+		nodeCommands: []byte(`commands:
+  - key: kubeAPIServerSpecFilePermission
+    nodeType: master
+    audit: stat -c %a $apiserver.confs
+  - key: kubeletConfFilePermissions
+    nodeType: worker
+    audit: stat -c %a $kubelet.confs
+`),
+	}
+	assert.NoError(t, nc.resolve(context.Background()))
+	assert.Equal(t, []string{WorkerNode}, nc.roles)
+	assert.Equal(t, []Command{
+		{Key: "kubeAPIServerSpecFilePermission", NodeType: MasterNode, Audit: "stat -c %a $apiserver.confs"},
+		{Key: "kubeletConfFilePermissions", NodeType: WorkerNode, Audit: "stat -c %a /var/lib/kubelet/config.yaml"},
+	}, nc.commands)
+
+	// config created and node promoted to control plane after start
+	shell["ls /etc/kubernetes/kubelet/config.yaml"] = "/etc/kubernetes/kubelet/config.yaml"
+	shell["ls /etc/kubernetes/manifests/kube-apiserver.yaml"] = "/etc/kubernetes/manifests/kube-apiserver.yaml"
+	shell["ps "] = "COMMAND,kube-apiserver --secure-port=6443,/usr/bin/kubelet"
+	assert.NoError(t, nc.resolve(context.Background()))
+	assert.Equal(t, []string{MasterNode, WorkerNode}, nc.roles)
+	assert.Equal(t, []Command{
+		{Key: "kubeAPIServerSpecFilePermission", NodeType: MasterNode, Audit: "stat -c %a /etc/kubernetes/manifests/kube-apiserver.yaml"},
+		{Key: "kubeletConfFilePermissions", NodeType: WorkerNode, Audit: "stat -c %a /etc/kubernetes/kubelet/config.yaml"},
+	}, nc.commands)
+}
+
 func CompressAndEncode(data []byte) (string, error) {
 	cm, err := bzip2Compress(data)
 	if err != nil {
@@ -3,11 +3,57 @@ package collector
 import (
 	"bytes"
 	"compress/bzip2"
+	"compress/gzip"
 	"encoding/base64"
+	"fmt"
 	"io"
 	"strings"
+	"unicode/utf8"
+
+	bzip2w "github.com/dsnet/compress/bzip2"
+)
+
+const (
+	// CompressionBzip2 bzip2 payload compression
+	CompressionBzip2 = "bzip2"
+	// CompressionGzip gzip payload compression
+	CompressionGzip = "gzip"
+	// CompressionNone uncompressed payload
+	CompressionNone = "none"
+)
+
+var (
+	bzip2Magic = []byte("BZh")
+	gzipMagic  = []byte{0x1f, 0x8b}
 )
 
+// EncodeWithCompression compress data with the given compression (bzip2, gzip or none) and base64 encode it
+func EncodeWithCompression(data []byte, compression string) (string, error) {
+	var buf bytes.Buffer
+	var w io.WriteCloser
+	var err error
+	switch compression {
+	case CompressionBzip2:
+		w, err = bzip2w.NewWriter(&buf, &bzip2w.WriterConfig{Level: bzip2w.DefaultCompression})
+	case CompressionGzip:
+		w = gzip.NewWriter(&buf)
+	case CompressionNone:
+		return base64.StdEncoding.EncodeToString(data), nil
+	default:
+		return "", fmt.Errorf("compression %s is not supported", compression)
+	}
+	if err != nil {
+		return "", err
+	}
+	if _, err := w.Write(data); err != nil {
+		return "", err
+	}
+	if err := w.Close(); err != nil {
+		return "", err
+	}
+	return base64.StdEncoding.EncodeToString(buf.Bytes()), nil
+}
+
 // decompressBzip2 accept bzip2 compressed bytes and decompress it
 // #nosec
 func decompressBzip2(compressedBytes []byte) (io.Reader, error) {
@@ -21,26 +67,80 @@ func decompressBzip2(compressedBytes []byte) (io.Reader, error) {
 	return uncompressedWriter, nil
 }
 
-// base64Decode accept encoded reader and base64 decode it
-func base64Decode(encodedReader io.Reader) ([]byte, error) {
-	encodedBytes, err := io.ReadAll(encodedReader)
+// decompressGzip accept gzip compressed bytes and decompress it
+// #nosec
+func decompressGzip(compressedBytes []byte) (io.Reader, error) {
+	gzReader, err := gzip.NewReader(bytes.NewReader(compressedBytes))
+	if err != nil {
+		return nil, err
+	}
+	defer gzReader.Close()
+	uncompressedWriter := new(bytes.Buffer)
+	//nolint:gosec
+	_, err = io.Copy(uncompressedWriter, gzReader)
 	if err != nil {
 		return nil, err
 	}
-	return base64.StdEncoding.DecodeString(string(bytes.TrimSpace(encodedBytes)))
+	return uncompressedWriter, nil
 }
 
-func uncompressAndDecode(kubeletConfig string) ([]byte, error) {
-	encodedReader := io.NopCloser(strings.NewReader(kubeletConfig))
-	// base64 decode logs
-	compressedLogsBytes, err := base64Decode(encodedReader)
+// isCompressed check if data start with bzip2 or gzip magic bytes
+func isCompressed(data []byte) bool {
+	return bytes.HasPrefix(data, bzip2Magic) || bytes.HasPrefix(data, gzipMagic)
+}
+
+// decompress detect data compression (bzip2, gzip or none) by magic bytes and decompress it
+func decompress(data []byte) ([]byte, error) {
+	var reader io.Reader
+	var err error
+	switch {
+	case bytes.HasPrefix(data, bzip2Magic):
+		reader, err = decompressBzip2(data)
+	case bytes.HasPrefix(data, gzipMagic):
+		reader, err = decompressGzip(data)
+	default:
+		return data, nil
+	}
 	if err != nil {
 		return nil, err
 	}
-	// bzip2 decompress logs
-	unCompressedLogsReader, err := decompressBzip2(compressedLogsBytes)
+	return io.ReadAll(reader)
+}
+
+// base64Payload payload without line breaks and whether it only contain base64 alphabet characters,
+// base64 payloads may be wrapped on several lines
+func base64Payload(payload []byte) (string, bool) {
+	encoded := strings.NewReplacer("\r", "", "\n", "").Replace(strings.TrimSpace(string(payload)))
+	for _, r := range encoded {
+		if !(r >= 'A' && r <= 'Z' || r >= 'a' && r <= 'z' || r >= '0' && r <= '9' || r == '+' || r == '/' || r == '=') {
+			return encoded, false
+		}
+	}
+	return encoded, true
+}
+
+// decodePayload accept raw payload which can be compressed, base64 encoded (with or without compression)
+// or plain text (yaml / json) and return it decoded and uncompressed content. payloads with characters outside
+// the base64 alphabet, example: yaml or json, are plain text, other payloads must be valid base64
+func decodePayload(payload []byte) ([]byte, error) {
+	if isCompressed(payload) {
+		return decompress(payload)
+	}
+	encoded, ok := base64Payload(payload)
+	if !ok {
+		// plain text payload
+		return payload, nil
+	}
+	decoded, err := base64.StdEncoding.DecodeString(encoded)
 	if err != nil {
-		return nil, err
+		return nil, fmt.Errorf("invalid base64 payload: %w", err)
 	}
-	return io.ReadAll(unCompressedLogsReader)
+	if !isCompressed(decoded) && !utf8.Valid(decoded) {
+		return nil, fmt.Errorf("invalid base64 payload: decoded content is neither compressed nor text")
+	}
+	return decompress(decoded)
+}
+
+func uncompressAndDecode(kubeletConfig string) ([]byte, error) {
+	return decodePayload([]byte(kubeletConfig))
 }
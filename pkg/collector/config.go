@@ -0,0 +1,36 @@
+package collector
+
+import (
+	"embed"
+	"fmt"
+)
+
+var (
+	//go:embed config/config.yaml
+	defaultNodeConfig []byte
+
+	//go:embed config/kubeletconfig-mapping.yaml
+	defaultKubeletConfigMapping []byte
+
+	//go:embed config/specs/*.yaml
+	specsFS embed.FS
+)
+
+const specsDir = "config/specs"
+
+// loadSpec load embedded collector spec by name, example: k8s-cis-1.23.0
+func loadSpec(name string) ([]byte, error) {
+	data, err := specsFS.ReadFile(fmt.Sprintf("%s/%s.yaml", specsDir, name))
+	if err != nil {
+		return nil, fmt.Errorf("spec %s not found", name)
+	}
+	return data, nil
+}
+
+// specName build spec name from spec-name and spec-version flags, default to basic k8s spec
+func specName(name string, version string) string {
+	if name == "" || version == "" {
+		return defaultSpec
+	}
+	return fmt.Sprintf("%s-%s", name, version)
+}
@@ -0,0 +1,110 @@
+package collector
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+
+	"sigs.k8s.io/yaml"
+)
+
+const (
+	// ProbeEncryption inspect API server encryption at rest configuration set by --encryption-provider-config
+	ProbeEncryption = "encryption"
+
+	encryptionProviderIdentity = "identity"
+	encryptionProviderKMS      = "kms"
+	encryptionProviderKMSv2    = "kmsv2"
+	encryptionKMSv2APIVersion  = "v2"
+)
+
+// encryptionConfiguration apiserver.config.k8s.io EncryptionConfiguration, providers are kept raw so key material is never decoded
+type encryptionConfiguration struct {
+	Kind      string                  `json:"kind"`
+	Resources []encryptionResourceRaw `json:"resources"`
+}
+
+type encryptionResourceRaw struct {
+	Resources []string                     `json:"resources"`
+	Providers []map[string]json.RawMessage `json:"providers"`
+}
+
+// EncryptionInfo encryption probe result, key material is never reported
+type EncryptionInfo struct {
+	// ConfigFile host path of --encryption-provider-config, empty when the flag is not set
+	ConfigFile  string `json:"configFile,omitempty"`
+	ConfigFound bool   `json:"configFound"`
+	// Permissions config file permissions in octal, example: 600
+	Permissions string               `json:"permissions,omitempty"`
+	Resources   []EncryptionResource `json:"resources,omitempty"`
+}
+
+// EncryptionResource resources ordered provider types, the first provider encrypt new writes
+type EncryptionResource struct {
+	Resources     []string `json:"resources"`
+	Providers     []string `json:"providers"`
+	IdentityFirst bool     `json:"identityFirst"`
+}
+
+// encryptionProbe resources and providers of the --encryption-provider-config file of a component config, example: apiserver
+func encryptionProbe(ctx ProbeContext, arg string) ([]interface{}, error) {
+	pod, ok, err := probeStaticPod(ctx, arg)
+	if err != nil {
+		return nil, err
+	}
+	if !ok {
+		return []interface{}{}, nil
+	}
+	info := EncryptionInfo{}
+	configFile := pod.Flags["--encryption-provider-config"]
+	if configFile == "" {
+		return []interface{}{info}, nil
+	}
+	info.ConfigFile = hostPath(pod, configFile)
+	path := filepath.Join(ctx.Root, info.ConfigFile)
+	if fi, err := os.Stat(path); err == nil {
+		info.Permissions = fmt.Sprintf("%o", fi.Mode().Perm())
+	}
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return []interface{}{info}, nil
+	}
+	var config encryptionConfiguration
+	if err := yaml.Unmarshal(data, &config); err != nil || config.Kind != "EncryptionConfiguration" {
+		return []interface{}{info}, nil
+	}
+	info.ConfigFound = true
+	for _, r := range config.Resources {
+		resource := EncryptionResource{Resources: r.Resources, Providers: make([]string, 0, len(r.Providers))}
+		for _, p := range r.Providers {
+			resource.Providers = append(resource.Providers, providerType(p))
+		}
+		resource.IdentityFirst = len(resource.Providers) > 0 && resource.Providers[0] == encryptionProviderIdentity
+		info.Resources = append(info.Resources, resource)
+	}
+	return []interface{}{info}, nil
+}
+
+// providerType provider entry type, kms providers are reported as kms (v1) or kmsv2 by their apiVersion
+func providerType(provider map[string]json.RawMessage) string {
+	names := make([]string, 0, len(provider))
+	for name := range provider {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	if len(names) == 0 {
+		return ""
+	}
+	name := names[0]
+	if name == encryptionProviderKMS {
+		var kms struct {
+			APIVersion string `json:"apiVersion"`
+		}
+		if err := json.Unmarshal(provider[name], &kms); err == nil && kms.APIVersion == encryptionKMSv2APIVersion {
+			return encryptionProviderKMSv2
+		}
+	}
+	return name
+}
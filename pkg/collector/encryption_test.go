@@ -0,0 +1,103 @@
+package collector
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestEncryptionProbe(t *testing.T) {
+	manifest := `apiVersion: v1
+kind: Pod
+metadata:
+  name: kube-apiserver
+spec:
+  containers:
+  - name: kube-apiserver
+    command:
+    - kube-apiserver
+    - --encryption-provider-config=/etc/kubernetes/enc/encryption.yaml
+    volumeMounts:
+    - mountPath: /etc/kubernetes/enc
+      name: enc
+      readOnly: true
+  volumes:
+  - hostPath:
+      path: /etc/kubernetes/enc
+    name: enc
+`
+	config := `apiVersion: apiserver.config.k8s.io/v1
+kind: EncryptionConfiguration
+resources:
+- resources:
+  - secrets
+  providers:
+  - kms:
+      apiVersion: v2
+      name: vault
+      endpoint: unix:///var/run/kms.sock
+  - aescbc:
+      keys:
+      - name: key1
+        secret: c2VjcmV0IGlzIHNlY3VyZQ==
+  - identity: {}
+- resources:
+  - configmaps
+  - events.events.k8s.io
+  providers:
+  - identity: {}
+  - aesgcm:
+      keys:
+      - name: key1
+        secret: c2VjcmV0IGlzIHNlY3VyZQ==
+  - kms:
+      name: legacy
+  - secretbox:
+      keys:
+      - name: key1
+        secret: YWJjZGVmZ2hpamtsbW5vcHFyc3R1dnd4eXoxMjM0NTY=
+`
+	root := writeFiles(t, map[string]string{
+		"/etc/kubernetes/manifests/kube-apiserver.yaml": manifest,
+		"/etc/kubernetes/enc/encryption.yaml":           config,
+	})
+	assert.NoError(t, os.Chmod(filepath.Join(root, "/etc/kubernetes/enc/encryption.yaml"), 0o644))
+	ctx := ProbeContext{Root: root, Params: map[string]string{"$apiserver.confs": "/etc/kubernetes/manifests/kube-apiserver.yaml"}}
+
+	got, err := encryptionProbe(ctx, "apiserver")
+	assert.NoError(t, err)
+	assert.Equal(t, []interface{}{
+		EncryptionInfo{
+			ConfigFile:  "/etc/kubernetes/enc/encryption.yaml",
+			ConfigFound: true,
+			Permissions: "644",
+			Resources: []EncryptionResource{
+				{Resources: []string{"secrets"}, Providers: []string{"kmsv2", "aescbc", "identity"}},
+				{Resources: []string{"configmaps", "events.events.k8s.io"}, Providers: []string{"identity", "aesgcm", "kms", "secretbox"}, IdentityFirst: true},
+			},
+		},
+	}, got)
+
+	// key material is never emitted
+	data, err := json.Marshal(got)
+	assert.NoError(t, err)
+	assert.NotContains(t, string(data), "c2VjcmV0IGlzIHNlY3VyZQ==")
+	assert.NotContains(t, string(data), "unix:///var/run/kms.sock")
+}
+
+func TestEncryptionProbeWithoutConfig(t *testing.T) {
+	root := writeFiles(t, map[string]string{
+		"/etc/kubernetes/manifests/kube-apiserver.yaml": apiserverManifest,
+	})
+	ctx := ProbeContext{Root: root, Params: map[string]string{"$apiserver.confs": "/etc/kubernetes/manifests/kube-apiserver.yaml"}}
+
+	got, err := encryptionProbe(ctx, "")
+	assert.NoError(t, err)
+	assert.Equal(t, []interface{}{EncryptionInfo{}}, got)
+
+	_, err = encryptionProbe(ctx, "kubectl")
+	assert.EqualError(t, err, `unknown component "kubectl"`)
+}
@@ -0,0 +1,271 @@
+package collector
+
+import (
+	"bufio"
+	"bytes"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+
+	"sigs.k8s.io/yaml"
+)
+
+const (
+	// ProbeEtcd inspect etcd static pod, systemd unit, env-style or YAML config TLS and listen settings
+	ProbeEtcd = "etcd"
+
+	etcdFormatStaticPod   = "staticpod"
+	etcdFormatSystemd     = "systemd"
+	etcdFormatEnvironment = "environment"
+	etcdFormatConfig      = "config"
+	etcdFormatArgs        = "args"
+
+	etcdEnvPrefix   = "ETCD_"
+	etcdPeerPrefix  = "peer-"
+	etcdDefaultName = "default"
+)
+
+// EtcdInfo etcd probe result
+type EtcdInfo struct {
+	Path string `json:"path"`
+	// Format config format, one of staticpod, systemd, environment, config or args
+	Format string `json:"format"`
+	// ConfigFile YAML config file set by --config-file, its settings replace flags as etcd does
+	ConfigFile          string   `json:"configFile,omitempty"`
+	Name                string   `json:"name"`
+	DataDir             string   `json:"dataDir"`
+	ListenClientURLs    []string `json:"listenClientURLs,omitempty"`
+	ListenPeerURLs      []string `json:"listenPeerURLs,omitempty"`
+	AdvertiseClientURLs []string `json:"advertiseClientURLs,omitempty"`
+	ClientTLS           EtcdTLS  `json:"clientTLS"`
+	PeerTLS             EtcdTLS  `json:"peerTLS"`
+	// DataDirSeparateMount whether data dir is on a separate mount than the root file system, unset when mounts can not be read
+	DataDirSeparateMount *bool    `json:"dataDirSeparateMount,omitempty"`
+	Weaknesses           []string `json:"weaknesses,omitempty"`
+}
+
+// EtcdTLS etcd client or peer transport security settings
+type EtcdTLS struct {
+	CertFile       string `json:"certFile,omitempty"`
+	KeyFile        string `json:"keyFile,omitempty"`
+	TrustedCAFile  string `json:"trustedCAFile,omitempty"`
+	ClientCertAuth bool   `json:"clientCertAuth"`
+	AutoTLS        bool   `json:"autoTLS"`
+}
+
+// etcdProbe inspect comma separated etcd config files or glob patterns, example: $etcd.confs.
+// files which can not be read or hold no etcd settings are skipped
+func etcdProbe(ctx ProbeContext, arg string) ([]interface{}, error) {
+	patterns := make([]string, 0)
+	for _, entry := range strings.Split(arg, ",") {
+		if entry = strings.TrimSpace(entry); entry != "" {
+			patterns = append(patterns, entry)
+		}
+	}
+	values := make([]interface{}, 0)
+	for _, path := range probePaths(ctx.Root, patterns) {
+		settings, format, ok := etcdSettings(ctx.Root, path)
+		if !ok {
+			continue
+		}
+		info := EtcdInfo{Path: path, Format: format}
+		if configFile := settings["config-file"]; configFile != "" {
+			if data, err := os.ReadFile(filepath.Join(ctx.Root, configFile)); err == nil {
+				if config, ok := etcdConfigSettings(data); ok {
+					info.ConfigFile = configFile
+					settings = config
+				}
+			}
+		}
+		values = append(values, inspectEtcd(ctx, info, settings))
+	}
+	return values, nil
+}
+
+// etcdSettings etcd settings keyed by flag name without dashes, example: peer-cert-file
+func etcdSettings(root string, path string) (map[string]string, string, bool) {
+	if filepath.Ext(path) == ".service" {
+		info, ok := loadSystemdUnit(root, systemdUnitName(path))
+		return trimFlagNames(info.Flags), etcdFormatSystemd, ok
+	}
+	data, err := os.ReadFile(filepath.Join(root, path))
+	if err != nil {
+		return nil, "", false
+	}
+	if pod, ok := parseStaticPod(data); ok {
+		container, ok := componentContainer(pod, componentProcesses["etcd"])
+		if !ok {
+			return nil, "", false
+		}
+		return trimFlagNames(parseFlags(containerArgs(container), false)), etcdFormatStaticPod, true
+	}
+	if settings, ok := etcdEnvironmentSettings(data); ok {
+		return settings, etcdFormatEnvironment, true
+	}
+	if settings, ok := etcdConfigSettings(data); ok {
+		return settings, etcdFormatConfig, true
+	}
+	settings := trimFlagNames(parseFlags(argsFileFlags(data), false))
+	return settings, etcdFormatArgs, len(settings) > 0
+}
+
+func trimFlagNames(flags map[string]string) map[string]string {
+	settings := make(map[string]string, len(flags))
+	for name, value := range flags {
+		settings[strings.TrimLeft(name, "-")] = value
+	}
+	return settings
+}
+
+// etcdEnvironmentSettings env-style config, example: ETCD_PEER_AUTO_TLS=true in /etc/etcd/etcd.conf
+func etcdEnvironmentSettings(data []byte) (map[string]string, bool) {
+	settings := make(map[string]string)
+	for name, value := range parseEnvironmentFile(data) {
+		if strings.HasPrefix(name, etcdEnvPrefix) {
+			settings[strings.ReplaceAll(strings.ToLower(strings.TrimPrefix(name, etcdEnvPrefix)), "_", "-")] = value
+		}
+	}
+	return settings, len(settings) > 0
+}
+
+// etcdConfigSettings YAML config file, transport security sections are flattened to their flag names
+func etcdConfigSettings(data []byte) (map[string]string, bool) {
+	var config map[string]interface{}
+	if err := yaml.Unmarshal(data, &config); err != nil || len(config) == 0 {
+		return nil, false
+	}
+	settings := make(map[string]string)
+	for key, value := range config {
+		switch key {
+		case "client-transport-security", "peer-transport-security":
+			section, ok := value.(map[string]interface{})
+			if !ok {
+				continue
+			}
+			prefix := ""
+			if key == "peer-transport-security" {
+				prefix = etcdPeerPrefix
+			}
+			for k, v := range section {
+				settings[prefix+k] = settingValue(v)
+			}
+		default:
+			settings[key] = settingValue(value)
+		}
+	}
+	return settings, true
+}
+
+func settingValue(value interface{}) string {
+	switch v := value.(type) {
+	case nil:
+		return ""
+	case []interface{}:
+		values := make([]string, 0, len(v))
+		for _, item := range v {
+			values = append(values, settingValue(item))
+		}
+		return strings.Join(values, ",")
+	}
+	return fmt.Sprint(value)
+}
+
+func inspectEtcd(ctx ProbeContext, info EtcdInfo, settings map[string]string) EtcdInfo {
+	info.Name = settings["name"]
+	if info.Name == "" {
+		info.Name = etcdDefaultName
+	}
+	info.DataDir = settings["data-dir"]
+	if info.DataDir == "" {
+		info.DataDir = fmt.Sprintf("%s.etcd", info.Name)
+	}
+	info.ListenClientURLs = splitSetting(settings["listen-client-urls"])
+	info.ListenPeerURLs = splitSetting(settings["listen-peer-urls"])
+	info.AdvertiseClientURLs = splitSetting(settings["advertise-client-urls"])
+	info.ClientTLS = etcdTLS(settings, "")
+	info.PeerTLS = etcdTLS(settings, etcdPeerPrefix)
+	if filepath.IsAbs(info.DataDir) {
+		info.DataDirSeparateMount = separateMount(ctx.Root, info.DataDir)
+	}
+	info.Weaknesses = etcdWeaknesses(info)
+	return info
+}
+
+func etcdTLS(settings map[string]string, prefix string) EtcdTLS {
+	return EtcdTLS{
+		CertFile:       settings[prefix+"cert-file"],
+		KeyFile:        settings[prefix+"key-file"],
+		TrustedCAFile:  settings[prefix+"trusted-ca-file"],
+		ClientCertAuth: settingBool(settings[prefix+"client-cert-auth"]),
+		AutoTLS:        settingBool(settings[prefix+"auto-tls"]),
+	}
+}
+
+func settingBool(value string) bool {
+	b, err := strconv.ParseBool(value)
+	return err == nil && b
+}
+
+func splitSetting(value string) []string {
+	values := make([]string, 0)
+	for _, v := range strings.Split(value, ",") {
+		if v = strings.TrimSpace(v); v != "" {
+			values = append(values, v)
+		}
+	}
+	if len(values) == 0 {
+		return nil
+	}
+	return values
+}
+
+// separateMount whether path longest mount point prefix from /proc/1/mountinfo is not the root mount
+func separateMount(root string, path string) *bool {
+	data, err := os.ReadFile(filepath.Join(root, "/proc/1/mountinfo"))
+	if err != nil {
+		return nil
+	}
+	mountPoint := ""
+	scanner := bufio.NewScanner(bytes.NewReader(data))
+	for scanner.Scan() {
+		fields := strings.Fields(scanner.Text())
+		if len(fields) < 5 {
+			continue
+		}
+		mp := fields[4]
+		if (path == mp || strings.HasPrefix(path, strings.TrimSuffix(mp, "/")+"/")) && len(mp) > len(mountPoint) {
+			mountPoint = mp
+		}
+	}
+	if mountPoint == "" {
+		return nil
+	}
+	return boolPtr(mountPoint != "/")
+}
+
+// etcdWeaknesses plain http listen URLs, missing client certificate auth and self-signed auto TLS
+func etcdWeaknesses(info EtcdInfo) []string {
+	weaknesses := make([]string, 0)
+	for _, u := range append(append([]string{}, info.ListenClientURLs...), info.ListenPeerURLs...) {
+		if strings.HasPrefix(u, "http://") {
+			weaknesses = append(weaknesses, fmt.Sprintf("plain http listen URL %s", u))
+		}
+	}
+	for _, t := range []struct {
+		name string
+		tls  EtcdTLS
+	}{{"client", info.ClientTLS}, {"peer", info.PeerTLS}} {
+		if !t.tls.ClientCertAuth {
+			weaknesses = append(weaknesses, fmt.Sprintf("%s certificate auth disabled", t.name))
+		}
+		if t.tls.AutoTLS {
+			weaknesses = append(weaknesses, fmt.Sprintf("%s auto TLS enabled", t.name))
+		}
+	}
+	if len(weaknesses) == 0 {
+		return nil
+	}
+	return weaknesses
+}
@@ -0,0 +1,144 @@
+package collector
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestEtcdProbe(t *testing.T) {
+	root := writeFiles(t, map[string]string{
+		"/etc/kubernetes/manifests/etcd.yaml": `apiVersion: v1
+kind: Pod
+metadata:
+  name: etcd
+  namespace: kube-system
+spec:
+  containers:
+  - name: etcd
+    image: registry.k8s.io/etcd:3.5.10-0
+    command:
+    - etcd
+    - --name=master-1
+    - --data-dir=/var/lib/etcd
+    - --listen-client-urls=https://127.0.0.1:2379,https://10.0.0.1:2379
+    - --listen-peer-urls=https://10.0.0.1:2380
+    - --advertise-client-urls=https://10.0.0.1:2379
+    - --cert-file=/etc/kubernetes/pki/etcd/server.crt
+    - --key-file=/etc/kubernetes/pki/etcd/server.key
+    - --trusted-ca-file=/etc/kubernetes/pki/etcd/ca.crt
+    - --client-cert-auth=true
+    - --peer-cert-file=/etc/kubernetes/pki/etcd/peer.crt
+    - --peer-key-file=/etc/kubernetes/pki/etcd/peer.key
+    - --peer-trusted-ca-file=/etc/kubernetes/pki/etcd/ca.crt
+    - --peer-client-cert-auth=true
+`,
+		"/etc/etcd/etcd.conf": `# etcd environment
+ETCD_NAME=etcd-1
+ETCD_DATA_DIR="/data/etcd"
+ETCD_LISTEN_CLIENT_URLS="http://0.0.0.0:2379"
+ETCD_PEER_AUTO_TLS=true
+`,
+		"/var/snap/etcd/common/etcd.conf.yml": `name: etcd-2
+data-dir: /var/snap/etcd/common/data
+listen-client-urls: https://10.0.0.2:2379
+client-transport-security:
+  cert-file: /var/snap/etcd/common/server.crt
+  key-file: /var/snap/etcd/common/server.key
+  client-cert-auth: true
+  trusted-ca-file: /var/snap/etcd/common/ca.crt
+peer-transport-security:
+  auto-tls: true
+`,
+		"/proc/1/mountinfo": `22 1 8:1 / / rw,relatime shared:1 - ext4 /dev/sda1 rw
+30 22 8:17 / /var/lib/etcd rw,relatime shared:2 - ext4 /dev/sdb1 rw
+`,
+	})
+
+	got, err := etcdProbe(ProbeContext{Root: root}, "/etc/kubernetes/manifests/etcd.yaml,/etc/etcd/etcd.conf,/var/snap/etcd/common/*.yml,/etc/etcd/missing.conf")
+	assert.NoError(t, err)
+	assert.Equal(t, []interface{}{
+		EtcdInfo{
+			Path:                 "/etc/etcd/etcd.conf",
+			Format:               etcdFormatEnvironment,
+			Name:                 "etcd-1",
+			DataDir:              "/data/etcd",
+			ListenClientURLs:     []string{"http://0.0.0.0:2379"},
+			PeerTLS:              EtcdTLS{AutoTLS: true},
+			DataDirSeparateMount: boolPtr(false),
+			Weaknesses: []string{
+				"plain http listen URL http://0.0.0.0:2379",
+				"client certificate auth disabled",
+				"peer certificate auth disabled",
+				"peer auto TLS enabled",
+			},
+		},
+		EtcdInfo{
+			Path:                "/etc/kubernetes/manifests/etcd.yaml",
+			Format:              etcdFormatStaticPod,
+			Name:                "master-1",
+			DataDir:             "/var/lib/etcd",
+			ListenClientURLs:    []string{"https://127.0.0.1:2379", "https://10.0.0.1:2379"},
+			ListenPeerURLs:      []string{"https://10.0.0.1:2380"},
+			AdvertiseClientURLs: []string{"https://10.0.0.1:2379"},
+			ClientTLS: EtcdTLS{
+				CertFile:       "/etc/kubernetes/pki/etcd/server.crt",
+				KeyFile:        "/etc/kubernetes/pki/etcd/server.key",
+				TrustedCAFile:  "/etc/kubernetes/pki/etcd/ca.crt",
+				ClientCertAuth: true,
+			},
+			PeerTLS: EtcdTLS{
+				CertFile:       "/etc/kubernetes/pki/etcd/peer.crt",
+				KeyFile:        "/etc/kubernetes/pki/etcd/peer.key",
+				TrustedCAFile:  "/etc/kubernetes/pki/etcd/ca.crt",
+				ClientCertAuth: true,
+			},
+			DataDirSeparateMount: boolPtr(true),
+		},
+		EtcdInfo{
+			Path:             "/var/snap/etcd/common/etcd.conf.yml",
+			Format:           etcdFormatConfig,
+			Name:             "etcd-2",
+			DataDir:          "/var/snap/etcd/common/data",
+			ListenClientURLs: []string{"https://10.0.0.2:2379"},
+			ClientTLS: EtcdTLS{
+				CertFile:       "/var/snap/etcd/common/server.crt",
+				KeyFile:        "/var/snap/etcd/common/server.key",
+				TrustedCAFile:  "/var/snap/etcd/common/ca.crt",
+				ClientCertAuth: true,
+			},
+			PeerTLS:              EtcdTLS{AutoTLS: true},
+			DataDirSeparateMount: boolPtr(false),
+			Weaknesses:           []string{"peer certificate auth disabled", "peer auto TLS enabled"},
+		},
+	}, got)
+}
+
+func TestEtcdProbeConfigFile(t *testing.T) {
+	root := writeFiles(t, map[string]string{
+		"/etc/systemd/system/etcd.service": `[Service]
+ExecStart=/usr/bin/etcd --config-file=/etc/etcd/etcd.conf.yml --name=ignored
+`,
+		"/etc/etcd/etcd.conf.yml": `name: etcd-3
+listen-peer-urls: http://10.0.0.3:2380
+`,
+	})
+
+	got, err := etcdProbe(ProbeContext{Root: root}, "/etc/systemd/system/etcd.service")
+	assert.NoError(t, err)
+	assert.Equal(t, []interface{}{
+		EtcdInfo{
+			Path:           "/etc/systemd/system/etcd.service",
+			Format:         etcdFormatSystemd,
+			ConfigFile:     "/etc/etcd/etcd.conf.yml",
+			Name:           "etcd-3",
+			DataDir:        "etcd-3.etcd",
+			ListenPeerURLs: []string{"http://10.0.0.3:2380"},
+			Weaknesses: []string{
+				"plain http listen URL http://10.0.0.3:2380",
+				"client certificate auth disabled",
+				"peer certificate auth disabled",
+			},
+		},
+	}, got)
+}
@@ -0,0 +1,153 @@
+package collector
+
+import (
+	"bufio"
+	"bytes"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+)
+
+const (
+	cgroupV1 = "v1"
+	cgroupV2 = "v2"
+
+	selinuxEnforcing  = "enforcing"
+	selinuxPermissive = "permissive"
+	selinuxDisabled   = "disabled"
+
+	apparmorEnabled  = "enabled"
+	apparmorDisabled = "disabled"
+)
+
+// HostFacts node host context collected from /proc and /sys
+type HostFacts struct {
+	KernelVersion  string    `json:"kernelVersion,omitempty"`
+	OS             OSRelease `json:"os"`
+	CgroupVersion  string    `json:"cgroupVersion,omitempty"`
+	SwapEnabled    bool      `json:"swapEnabled"`
+	SELinux        string    `json:"selinux"`
+	AppArmor       string    `json:"apparmor"`
+	Seccomp        bool      `json:"seccomp"`
+	KernelLockdown string    `json:"kernelLockdown,omitempty"`
+	BootTime       string    `json:"bootTime,omitempty"`
+}
+
+// OSRelease node os identification from /etc/os-release
+type OSRelease struct {
+	ID         string `json:"id,omitempty"`
+	VersionID  string `json:"versionId,omitempty"`
+	PrettyName string `json:"prettyName,omitempty"`
+}
+
+// CollectHostFacts read host facts from /proc and /sys under root without executing shell commands,
+// facts which can not be read are left empty
+func CollectHostFacts(root string) *HostFacts {
+	release := osRelease(root)
+	return &HostFacts{
+		KernelVersion: readTrimmed(root, "/proc/sys/kernel/osrelease"),
+		OS: OSRelease{
+			ID:         release["ID"],
+			VersionID:  release["VERSION_ID"],
+			PrettyName: release["PRETTY_NAME"],
+		},
+		CgroupVersion:  cgroupVersion(root),
+		SwapEnabled:    swapEnabled(root),
+		SELinux:        selinuxMode(root),
+		AppArmor:       apparmorMode(root),
+		Seccomp:        seccompAvailable(root),
+		KernelLockdown: kernelLockdown(root),
+		BootTime:       bootTime(root),
+	}
+}
+
+func readTrimmed(root string, path string) string {
+	data, err := os.ReadFile(filepath.Join(root, path))
+	if err != nil {
+		return ""
+	}
+	return strings.TrimSpace(string(data))
+}
+
+func exists(root string, path string) bool {
+	_, err := os.Stat(filepath.Join(root, path))
+	return err == nil
+}
+
+// cgroupVersion v2 when unified hierarchy is mounted on /sys/fs/cgroup
+func cgroupVersion(root string) string {
+	switch {
+	case exists(root, "/sys/fs/cgroup/cgroup.controllers"):
+		return cgroupV2
+	case exists(root, "/sys/fs/cgroup"):
+		return cgroupV1
+	}
+	return ""
+}
+
+// swapEnabled any swap device listed in /proc/swaps after header line
+func swapEnabled(root string) bool {
+	lines := strings.Split(readTrimmed(root, "/proc/swaps"), "\n")
+	return len(lines) > 1
+}
+
+func selinuxMode(root string) string {
+	switch readTrimmed(root, "/sys/fs/selinux/enforce") {
+	case "1":
+		return selinuxEnforcing
+	case "0":
+		return selinuxPermissive
+	}
+	return selinuxDisabled
+}
+
+func apparmorMode(root string) string {
+	if strings.HasPrefix(readTrimmed(root, "/sys/module/apparmor/parameters/enabled"), "Y") {
+		return apparmorEnabled
+	}
+	return apparmorDisabled
+}
+
+// seccompAvailable kernel built with seccomp report process Seccomp field in /proc/<pid>/status, the host init
+// process status is read as the collector own process runs in a container
+func seccompAvailable(root string) bool {
+	if exists(root, "/proc/sys/kernel/seccomp/actions_avail") {
+		return true
+	}
+	return statusField(readTrimmed(root, "/proc/1/status"), "Seccomp") != ""
+}
+
+// kernelLockdown selected lockdown mode, example: "none [integrity] confidentiality" => integrity
+func kernelLockdown(root string) string {
+	lockdown := readTrimmed(root, "/sys/kernel/security/lockdown")
+	start := strings.Index(lockdown, "[")
+	end := strings.Index(lockdown, "]")
+	if start < 0 || end < start {
+		return ""
+	}
+	return lockdown[start+1 : end]
+}
+
+// bootTime /proc/stat btime in RFC3339
+func bootTime(root string) string {
+	btime := statusField(readTrimmed(root, "/proc/stat"), "btime")
+	seconds, err := strconv.ParseInt(btime, 10, 64)
+	if err != nil {
+		return ""
+	}
+	return time.Unix(seconds, 0).UTC().Format(time.RFC3339)
+}
+
+// statusField value of "name: value" or "name value" line
+func statusField(data string, name string) string {
+	scanner := bufio.NewScanner(bytes.NewReader([]byte(data)))
+	for scanner.Scan() {
+		fields := strings.Fields(scanner.Text())
+		if len(fields) >= 2 && strings.TrimSuffix(fields[0], ":") == name {
+			return fields[1]
+		}
+	}
+	return ""
+}
@@ -0,0 +1,67 @@
+package collector
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestCollectHostFacts(t *testing.T) {
+	tests := []struct {
+		name  string
+		files map[string]string
+		want  *HostFacts
+	}{
+		{
+			name: "ubuntu cgroup v2 apparmor",
+			files: map[string]string{
+				"/proc/sys/kernel/osrelease":              "6.5.0-1020-aws\n",
+				"/etc/os-release":                         "PRETTY_NAME=\"Ubuntu 22.04.4 LTS\"\nNAME=\"Ubuntu\"\nVERSION_ID=\"22.04\"\nID=ubuntu\n",
+				"/sys/fs/cgroup/cgroup.controllers":       "cpuset cpu io memory hugetlb pids rdma misc\n",
+				"/proc/swaps":                             "Filename\t\t\t\tType\t\tSize\t\tUsed\t\tPriority\n",
+				"/sys/module/apparmor/parameters/enabled": "Y\n",
+				"/proc/1/status":                          "Name:\tsystemd\nSeccomp:\t0\nSeccomp_filters:\t0\n",
+				"/sys/kernel/security/lockdown":           "[none] integrity confidentiality\n",
+				"/proc/stat":                              "cpu  1 2 3 4\nbtime 1700000000\nprocesses 1234\n",
+			},
+			want: &HostFacts{
+				KernelVersion:  "6.5.0-1020-aws",
+				OS:             OSRelease{ID: "ubuntu", VersionID: "22.04", PrettyName: "Ubuntu 22.04.4 LTS"},
+				CgroupVersion:  cgroupV2,
+				SELinux:        selinuxDisabled,
+				AppArmor:       apparmorEnabled,
+				Seccomp:        true,
+				KernelLockdown: "none",
+				BootTime:       "2023-11-14T22:13:20Z",
+			},
+		},
+		{
+			name: "rhel cgroup v1 selinux swap",
+			files: map[string]string{
+				"/proc/sys/kernel/osrelease":                  "4.18.0-513.el8.x86_64",
+				"/sys/fs/cgroup/memory/memory.limit_in_bytes": "9223372036854771712",
+				"/proc/swaps":                            "Filename\tType\tSize\tUsed\tPriority\n/dev/dm-1\tpartition\t4194300\t0\t-2\n",
+				"/sys/fs/selinux/enforce":                "1",
+				"/proc/sys/kernel/seccomp/actions_avail": "kill_process kill_thread trap errno user_notif trace log allow",
+			},
+			want: &HostFacts{
+				KernelVersion: "4.18.0-513.el8.x86_64",
+				CgroupVersion: cgroupV1,
+				SwapEnabled:   true,
+				SELinux:       selinuxEnforcing,
+				AppArmor:      apparmorDisabled,
+				Seccomp:       true,
+			},
+		},
+		{
+			name:  "nothing readable",
+			files: map[string]string{},
+			want:  &HostFacts{SELinux: selinuxDisabled, AppArmor: apparmorDisabled},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.want, CollectHostFacts(writeFiles(t, tt.files)))
+		})
+	}
+}
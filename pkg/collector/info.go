@@ -21,11 +21,17 @@ func LoadConfigParams(nodeFileconfig string) (*Config, error) {
 	}
 	decodedNodeFileconfig, err := uncompressAndDecode(nodeFileconfig)
 	if err != nil {
-		fmt.Println("failed to read node file config")
-		return nil, err
+		return nil, fmt.Errorf("failed to read node file config: %w", err)
+	}
+	return parseConfigParams(decodedNodeFileconfig)
+}
+
+func parseConfigParams(nodeFileconfig []byte) (*Config, error) {
+	if len(nodeFileconfig) == 0 {
+		return nil, fmt.Errorf("node file config is empty")
 	}
 	var np Config
-	err = yaml.Unmarshal(decodedNodeFileconfig, &np)
+	err := yaml.Unmarshal(nodeFileconfig, &np)
 	if err != nil {
 		return nil, err
 	}
@@ -40,11 +46,17 @@ func LoadKubeletMapping(kubletConfigMapping string) (map[string]string, error) {
 	}
 	fContent, err = uncompressAndDecode(kubletConfigMapping)
 	if err != nil {
-		fmt.Println("failed to read nodekubletConfigMapping")
-		return nil, err
+		return nil, fmt.Errorf("failed to read kubelet config mapping: %w", err)
+	}
+	return parseKubeletMapping(fContent)
+}
+
+func parseKubeletMapping(kubletConfigMapping []byte) (map[string]string, error) {
+	if len(kubletConfigMapping) == 0 {
+		return nil, fmt.Errorf("kubletConfigMapping is empty")
 	}
 	mapping := make(map[string]string)
-	err = yaml.Unmarshal(fContent, &mapping)
+	err := yaml.Unmarshal(kubletConfigMapping, &mapping)
 	if err != nil {
 		return nil, err
 	}
@@ -53,18 +65,22 @@ func LoadKubeletMapping(kubletConfigMapping string) (map[string]string, error) {
 
 // SpecInfo spec info with require comand to collect
 type SpecInfo struct {
-	Version  string    `yaml:"version"`
-	Name     string    `yaml:"name"`
-	Title    string    `yaml:"title"`
-	Commands []Command `yaml:"commands"`
+	Version  string    `yaml:"version" json:"version"`
+	Name     string    `yaml:"name" json:"name"`
+	Title    string    `yaml:"title" json:"title"`
+	Commands []Command `yaml:"commands" json:"commands"`
 }
 
 // Collector details of info to collect
 type Command struct {
-	Key      string `yaml:"key"`
-	Title    string `yaml:"title"`
-	Audit    string `yaml:"audit"`
-	NodeType string `yaml:"nodeType"`
+	ID        string   `yaml:"id,omitempty" json:"id,omitempty"`
+	Key       string   `yaml:"key" json:"key"`
+	Title     string   `yaml:"title" json:"title"`
+	Audit     string   `yaml:"audit" json:"audit"`
+	NodeType  string   `yaml:"nodeType" json:"nodeType"`
+	Platforms []string `yaml:"platforms,omitempty" json:"platforms,omitempty"`
+	// Probe native probe executed instead of shell audit command, audit is the probe argument
+	Probe string `yaml:"probe,omitempty" json:"probe,omitempty"`
 }
 
 // Node output node data with info results
@@ -73,12 +89,20 @@ type Node struct {
 	Kind       string            `json:"kind"`
 	Metadata   map[string]string `json:"metadata"`
 	Type       string            `json:"type"`
+	Roles      []string          `json:"roles,omitempty"`
 	Info       map[string]*Info  `json:"info"`
+	Facts      *HostFacts        `json:"facts,omitempty"`
 }
 
 // Info comand output result
 type Info struct {
 	Values interface{} `json:"values"`
+	// Redacted names of the redaction rules which replaced a secret in values
+	Redacted []string `json:"redacted,omitempty"`
+	// Cached values read from the result cache, the command was not executed
+	Cached bool `json:"cached,omitempty"`
+	// Error probe error, values are empty when set
+	Error string `json:"error,omitempty"`
 }
 
 type Config struct {
@@ -105,6 +129,19 @@ type NodeParams struct {
 	VersionMapping    map[string]string `yaml:"version_mapping"`
 }
 
+// Components node params by component name as used by commands variables, example: $kubelet.confs
+func (np NodeParams) Components() map[string]Params {
+	return map[string]Params{
+		"apiserver":         np.APIserver,
+		"controllermanager": np.ControllerManager,
+		"scheduler":         np.Scheduler,
+		"etcd":              np.Etcd,
+		"proxy":             np.Proxy,
+		"kubelet":           np.KubeLet,
+		"flanneld":          np.Flanneld,
+	}
+}
+
 type Params struct {
 	Config            []string `yaml:"confs,omitempty"`
 	DefaultConfig     string   `yaml:"defaultconf,omitempty"`
@@ -119,3 +156,17 @@ type Params struct {
 	CAFile            []string `yaml:"cafile,omitempty"`
 	DefaultCAFile     string   `yaml:"defaultcafile,omitempty"`
 }
+
+// Paths all config, kubeconfig, data dirs, services and CA file paths of params
+func (p Params) Paths() []string {
+	paths := make([]string, 0)
+	for _, list := range [][]string{p.Config, p.KubeConfig, p.DataDirs, p.Services, p.CAFile} {
+		paths = append(paths, list...)
+	}
+	for _, path := range []string{p.DefaultConfig, p.DefaultKubeConfig, p.DefaultDataDir, p.DefalutServices, p.DefaultCAFile} {
+		if path != "" {
+			paths = append(paths, path)
+		}
+	}
+	return paths
+}
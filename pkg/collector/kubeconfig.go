@@ -0,0 +1,175 @@
+package collector
+
+import (
+	"crypto/x509"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+	"time"
+
+	"k8s.io/client-go/tools/clientcmd"
+	clientcmdapi "k8s.io/client-go/tools/clientcmd/api"
+)
+
+const (
+	// ProbeKubeconfig inspect kubeconfig files without emitting secret material
+	ProbeKubeconfig = "kubeconfig"
+
+	authTypeClientCertificate = "client-certificate"
+	authTypeToken             = "token"
+	authTypeExec              = "exec"
+	authTypeAuthProvider      = "auth-provider"
+	authTypeBasic             = "basic"
+	authTypeNone              = "none"
+
+	credentialsEmbedded = "embedded"
+	credentialsFile     = "file"
+	credentialsNone     = "none"
+)
+
+// bootstrapTokenRe kubelet TLS bootstrap token format, example: abcdef.0123456789abcdef
+var bootstrapTokenRe = regexp.MustCompile(`^[a-z0-9]{6}\.[a-z0-9]{16}$`)
+
+// KubeconfigInfo kubeconfig probe result of the current context, secret material is never reported
+type KubeconfigInfo struct {
+	Path                  string `json:"path"`
+	Context               string `json:"context,omitempty"`
+	Server                string `json:"server,omitempty"`
+	InsecureSkipTLSVerify bool   `json:"insecureSkipTLSVerify"`
+	CertificateAuthority  string `json:"certificateAuthority"`
+	AuthType              string `json:"authType"`
+	Credentials           string `json:"credentials"`
+	// ClientCertificateNotAfter client certificate expiry, unset when no client certificate can be read
+	ClientCertificateNotAfter     string `json:"clientCertificateNotAfter,omitempty"`
+	ClientCertificateDaysToExpiry *int   `json:"clientCertificateDaysToExpiry,omitempty"`
+	// BootstrapToken any user authenticate with a kubelet TLS bootstrap token
+	BootstrapToken bool `json:"bootstrapToken"`
+}
+
+// kubeconfigProbe inspect comma separated kubeconfig files or glob patterns, example: /etc/kubernetes/kubelet.conf.
+// files which can not be read or parsed are skipped
+func kubeconfigProbe(ctx ProbeContext, arg string) ([]interface{}, error) {
+	patterns := make([]string, 0)
+	for _, entry := range strings.Split(arg, ",") {
+		if entry = strings.TrimSpace(entry); entry != "" {
+			patterns = append(patterns, entry)
+		}
+	}
+	values := make([]interface{}, 0)
+	for _, path := range probePaths(ctx.Root, patterns) {
+		data, err := os.ReadFile(filepath.Join(ctx.Root, path))
+		if err != nil {
+			continue
+		}
+		config, err := clientcmd.Load(data)
+		if err != nil || (len(config.Clusters) == 0 && len(config.AuthInfos) == 0) {
+			continue
+		}
+		values = append(values, inspectKubeconfig(ctx, path, config))
+	}
+	return values, nil
+}
+
+func inspectKubeconfig(ctx ProbeContext, path string, config *clientcmdapi.Config) KubeconfigInfo {
+	info := KubeconfigInfo{Path: path, CertificateAuthority: credentialsNone, AuthType: authTypeNone, Credentials: credentialsNone}
+	contextName, clusterName, userName := currentContext(config)
+	info.Context = contextName
+	if cluster, ok := config.Clusters[clusterName]; ok {
+		info.Server = cluster.Server
+		info.InsecureSkipTLSVerify = cluster.InsecureSkipTLSVerify
+		switch {
+		case len(cluster.CertificateAuthorityData) > 0:
+			info.CertificateAuthority = credentialsEmbedded
+		case cluster.CertificateAuthority != "":
+			info.CertificateAuthority = credentialsFile
+		}
+	}
+	if user, ok := config.AuthInfos[userName]; ok {
+		info.AuthType, info.Credentials = authInfo(user)
+		if certData := clientCertificate(ctx.Root, path, user); len(certData) > 0 {
+			certs, _ := parsePEM(certData)
+			if len(certs) > 0 {
+				info.ClientCertificateNotAfter = certs[0].NotAfter.UTC().Format(time.RFC3339)
+				days := daysToExpiry(certs[0], ctx.now())
+				info.ClientCertificateDaysToExpiry = &days
+			}
+		}
+	}
+	for _, user := range config.AuthInfos {
+		if bootstrapTokenRe.MatchString(user.Token) {
+			info.BootstrapToken = true
+		}
+	}
+	return info
+}
+
+// currentContext current context name, cluster and user names, default to the only or first sorted context
+func currentContext(config *clientcmdapi.Config) (string, string, string) {
+	name := config.CurrentContext
+	if _, ok := config.Contexts[name]; !ok {
+		name = ""
+		for n := range config.Contexts {
+			if name == "" || n < name {
+				name = n
+			}
+		}
+	}
+	if c, ok := config.Contexts[name]; ok {
+		return name, c.Cluster, c.AuthInfo
+	}
+	// kubeconfig without context, use its only cluster and user
+	var clusterName, userName string
+	for n := range config.Clusters {
+		clusterName = n
+	}
+	for n := range config.AuthInfos {
+		userName = n
+	}
+	return "", clusterName, userName
+}
+
+// authInfo user auth type and whether credentials are embedded or referenced by file
+func authInfo(user *clientcmdapi.AuthInfo) (string, string) {
+	switch {
+	case user.Exec != nil:
+		return authTypeExec, credentialsNone
+	case user.AuthProvider != nil:
+		return authTypeAuthProvider, credentialsEmbedded
+	case len(user.ClientCertificateData) > 0 || user.ClientCertificate != "":
+		if len(user.ClientKeyData) > 0 {
+			return authTypeClientCertificate, credentialsEmbedded
+		}
+		return authTypeClientCertificate, credentialsFile
+	case user.Token != "":
+		return authTypeToken, credentialsEmbedded
+	case user.TokenFile != "":
+		return authTypeToken, credentialsFile
+	case user.Username != "" || user.Password != "":
+		return authTypeBasic, credentialsEmbedded
+	}
+	return authTypeNone, credentialsNone
+}
+
+// clientCertificate embedded client certificate or referenced file content, relative to kubeconfig directory
+func clientCertificate(root string, kubeconfigPath string, user *clientcmdapi.AuthInfo) []byte {
+	if len(user.ClientCertificateData) > 0 {
+		return user.ClientCertificateData
+	}
+	if user.ClientCertificate == "" {
+		return nil
+	}
+	path := user.ClientCertificate
+	if !filepath.IsAbs(path) {
+		path = filepath.Join(filepath.Dir(kubeconfigPath), path)
+	}
+	data, err := os.ReadFile(filepath.Join(root, path))
+	if err != nil {
+		return nil
+	}
+	return data
+}
+
+func daysToExpiry(cert *x509.Certificate, now time.Time) int {
+	return int(cert.NotAfter.Sub(now).Hours() / 24)
+}
@@ -0,0 +1,129 @@
+package collector
+
+import (
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"math/big"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestKubeconfigProbe(t *testing.T) {
+	root := t.TempDir()
+	now := time.Date(2024, 6, 1, 0, 0, 0, 0, time.UTC)
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	assert.NoError(t, err)
+	writeCertificate(t, filepath.Join(root, "/var/lib/kubelet/pki/kubelet-client-current.pem"), &x509.Certificate{
+		SerialNumber: big.NewInt(1),
+		Subject:      pkix.Name{CommonName: "system:node:worker-1", Organization: []string{"system:nodes"}},
+		NotBefore:    now.AddDate(0, -1, 0),
+		NotAfter:     now.AddDate(0, 0, 10),
+	}, key.Public(), nil, key, key)
+
+	files := map[string]string{
+		"/etc/kubernetes/kubelet.conf": `apiVersion: v1
+kind: Config
+clusters:
+- cluster:
+    certificate-authority-data: LS0tLS1CRUdJTiBDRVJUSUZJQ0FURS0tLS0tCg==
+    server: https://10.0.0.1:6443
+  name: default-cluster
+contexts:
+- context:
+    cluster: default-cluster
+    namespace: default
+    user: default-auth
+  name: default-context
+current-context: default-context
+users:
+- name: default-auth
+  user:
+    client-certificate: /var/lib/kubelet/pki/kubelet-client-current.pem
+    client-key: /var/lib/kubelet/pki/kubelet-client-current.pem
+`,
+		"/etc/kubernetes/bootstrap-kubelet.conf": `apiVersion: v1
+kind: Config
+clusters:
+- cluster:
+    insecure-skip-tls-verify: true
+    server: https://10.0.0.1:6443
+  name: kubernetes
+contexts:
+- context:
+    cluster: kubernetes
+    user: tls-bootstrap-token-user
+  name: tls-bootstrap-token-user@kubernetes
+current-context: tls-bootstrap-token-user@kubernetes
+users:
+- name: tls-bootstrap-token-user
+  user:
+    token: abcdef.0123456789abcdef
+`,
+		"/etc/kubernetes/scheduler.conf": `apiVersion: v1
+kind: Config
+clusters:
+- cluster:
+    certificate-authority: pki/ca.crt
+    server: https://10.0.0.1:6443
+  name: kubernetes
+contexts:
+- context:
+    cluster: kubernetes
+    user: scheduler
+  name: scheduler@kubernetes
+users:
+- name: scheduler
+  user:
+    exec:
+      apiVersion: client.authentication.k8s.io/v1
+      command: aws-iam-authenticator
+`,
+		"/etc/kubernetes/invalid.conf": "not: [a kubeconfig",
+	}
+	for name, content := range files {
+		path := filepath.Join(root, name)
+		assert.NoError(t, os.MkdirAll(filepath.Dir(path), 0o755))
+		assert.NoError(t, os.WriteFile(path, []byte(content), 0o600))
+	}
+
+	got, err := kubeconfigProbe(ProbeContext{Root: root, Now: now}, "/etc/kubernetes/*.conf,/etc/kubernetes/missing.conf")
+	assert.NoError(t, err)
+	days := 10
+	assert.Equal(t, []interface{}{
+		KubeconfigInfo{
+			Path:                  "/etc/kubernetes/bootstrap-kubelet.conf",
+			Context:               "tls-bootstrap-token-user@kubernetes",
+			Server:                "https://10.0.0.1:6443",
+			InsecureSkipTLSVerify: true,
+			CertificateAuthority:  credentialsNone,
+			AuthType:              authTypeToken,
+			Credentials:           credentialsEmbedded,
+			BootstrapToken:        true,
+		},
+		KubeconfigInfo{
+			Path:                          "/etc/kubernetes/kubelet.conf",
+			Context:                       "default-context",
+			Server:                        "https://10.0.0.1:6443",
+			CertificateAuthority:          credentialsEmbedded,
+			AuthType:                      authTypeClientCertificate,
+			Credentials:                   credentialsFile,
+			ClientCertificateNotAfter:     "2024-06-11T00:00:00Z",
+			ClientCertificateDaysToExpiry: &days,
+		},
+		KubeconfigInfo{
+			Path:                 "/etc/kubernetes/scheduler.conf",
+			Context:              "scheduler@kubernetes",
+			Server:               "https://10.0.0.1:6443",
+			CertificateAuthority: credentialsFile,
+			AuthType:             authTypeExec,
+			Credentials:          credentialsNone,
+		},
+	}, got)
+}
@@ -0,0 +1,506 @@
+package collector
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"reflect"
+	"regexp"
+	"sort"
+	"strings"
+
+	"github.com/Masterminds/semver"
+	"github.com/spf13/cobra"
+	"gopkg.in/yaml.v3"
+	kubeletconfigv1beta1 "k8s.io/kubelet/config/v1beta1"
+)
+
+const (
+	// SeverityError lint finding which break collection
+	SeverityError = "error"
+	// SeverityWarning lint finding which is ignored by the collector
+	SeverityWarning = "warning"
+
+	kubeletConfigPrefix = "kubeletconfig."
+)
+
+var (
+	variableRe = regexp.MustCompile(`\$([A-Za-z]+)\.([A-Za-z]+)`)
+
+	// variableParams params list resolving each command variable field, example: $kubelet.confs
+	variableParams = map[string]func(Params) []string{
+		"bins":       func(p Params) []string { return p.Binaries },
+		"confs":      func(p Params) []string { return p.Config },
+		"kubeconfig": func(p Params) []string { return p.KubeConfig },
+		"datadirs":   func(p Params) []string { return p.DataDirs },
+		"svc":        func(p Params) []string { return p.Services },
+		"cafile":     func(p Params) []string { return p.CAFile },
+	}
+)
+
+// Diagnostic lint finding in spec, node config or kubelet config mapping file
+type Diagnostic struct {
+	File     string `json:"file"`
+	Line     int    `json:"line"`
+	Column   int    `json:"column"`
+	Severity string `json:"severity"`
+	Message  string `json:"message"`
+}
+
+func (d Diagnostic) String() string {
+	return fmt.Sprintf("%s:%d:%d: %s: %s", d.File, d.Line, d.Column, d.Severity, d.Message)
+}
+
+type lintInput struct {
+	name string
+	data []byte
+}
+
+type linter struct {
+	file        string
+	config      *Config
+	diagnostics []Diagnostic
+}
+
+// Lint validate spec, node config and kubelet config mapping files and print line numbered diagnostics,
+// the built-in files are validated when no file is given
+func Lint(cmd *cobra.Command, paths []string) error {
+	inputs, err := lintInputs(paths)
+	if err != nil {
+		return err
+	}
+	diagnostics := lintFiles(inputs)
+	var errCount int
+	for _, d := range diagnostics {
+		fmt.Fprintln(cmd.OutOrStdout(), d.String())
+		if d.Severity == SeverityError {
+			errCount++
+		}
+	}
+	if errCount > 0 {
+		return fmt.Errorf("lint found %d error(s)", errCount)
+	}
+	return nil
+}
+
+func lintInputs(paths []string) ([]lintInput, error) {
+	inputs := make([]lintInput, 0)
+	if len(paths) == 0 {
+		inputs = append(inputs,
+			lintInput{name: "config/config.yaml", data: defaultNodeConfig},
+			lintInput{name: "config/kubeletconfig-mapping.yaml", data: defaultKubeletConfigMapping})
+		entries, err := specsFS.ReadDir(specsDir)
+		if err != nil {
+			return nil, err
+		}
+		for _, e := range entries {
+			name := filepath.Join(specsDir, e.Name())
+			data, err := specsFS.ReadFile(name)
+			if err != nil {
+				return nil, err
+			}
+			inputs = append(inputs, lintInput{name: name, data: data})
+		}
+		return inputs, nil
+	}
+	for _, path := range paths {
+		data, err := os.ReadFile(path)
+		if err != nil {
+			return nil, err
+		}
+		inputs = append(inputs, lintInput{name: path, data: data})
+	}
+	return inputs, nil
+}
+
+// lintFiles lint inputs, spec variables are checked against linted node config or the built-in one
+func lintFiles(inputs []lintInput) []Diagnostic {
+	diagnostics := make([]Diagnostic, 0)
+	roots := make([]*yaml.Node, len(inputs))
+	var config *Config
+	for i, in := range inputs {
+		var doc yaml.Node
+		if err := yaml.Unmarshal(in.data, &doc); err != nil {
+			diagnostics = append(diagnostics, Diagnostic{File: in.name, Severity: SeverityError, Message: err.Error()})
+			continue
+		}
+		if doc.Kind != yaml.DocumentNode || len(doc.Content) == 0 {
+			diagnostics = append(diagnostics, Diagnostic{File: in.name, Severity: SeverityError, Message: "file is empty"})
+			continue
+		}
+		roots[i] = doc.Content[0]
+		if config == nil && yamlKind(roots[i]) == PayloadKindNodeConfig {
+			if np, err := parseConfigParams(in.data); err == nil {
+				config = np
+			}
+		}
+	}
+	if config == nil {
+		config, _ = parseConfigParams(defaultNodeConfig)
+	}
+	for i, in := range inputs {
+		if roots[i] == nil {
+			continue
+		}
+		l := &linter{file: in.name, config: config}
+		l.duplicateKeys(roots[i])
+		switch yamlKind(roots[i]) {
+		case PayloadKindCommands:
+			l.lintSpec(roots[i])
+		case PayloadKindNodeConfig:
+			l.lintNodeConfig(roots[i])
+		case PayloadKindKubeletConfigMapping:
+			l.lintMapping(roots[i])
+		default:
+			l.report(roots[i], SeverityError, "unable to detect file kind, expected spec, node config or kubelet config mapping")
+		}
+		diagnostics = append(diagnostics, l.diagnostics...)
+	}
+	sort.SliceStable(diagnostics, func(i, j int) bool {
+		if diagnostics[i].File != diagnostics[j].File {
+			return diagnostics[i].File < diagnostics[j].File
+		}
+		return diagnostics[i].Line < diagnostics[j].Line
+	})
+	return diagnostics
+}
+
+// yamlKind detect file kind from the root node keys
+func yamlKind(root *yaml.Node) string {
+	if root.Kind != yaml.MappingNode || len(root.Content) == 0 {
+		return PayloadKindUnknown
+	}
+	if _, v := mappingValue(root, "commands"); v != nil {
+		return PayloadKindCommands
+	}
+	if _, v := mappingValue(root, "node"); v != nil {
+		return PayloadKindNodeConfig
+	}
+	if _, v := mappingValue(root, "version_mapping"); v != nil {
+		return PayloadKindNodeConfig
+	}
+	for i := 1; i < len(root.Content); i += 2 {
+		v := root.Content[i]
+		if v.Kind != yaml.ScalarNode || !strings.HasPrefix(v.Value, kubeletConfigPrefix) {
+			return PayloadKindUnknown
+		}
+	}
+	return PayloadKindKubeletConfigMapping
+}
+
+func (l *linter) report(node *yaml.Node, severity string, format string, args ...interface{}) {
+	l.diagnostics = append(l.diagnostics, Diagnostic{
+		File:     l.file,
+		Line:     node.Line,
+		Column:   node.Column,
+		Severity: severity,
+		Message:  fmt.Sprintf(format, args...),
+	})
+}
+
+// duplicateKeys report mapping keys defined more than once
+func (l *linter) duplicateKeys(node *yaml.Node) {
+	if node.Kind == yaml.MappingNode {
+		seen := make(map[string]int)
+		for i := 0; i < len(node.Content); i += 2 {
+			k := node.Content[i]
+			if line, ok := seen[k.Value]; ok {
+				l.report(k, SeverityError, "duplicate key %q, first defined at line %d", k.Value, line)
+				continue
+			}
+			seen[k.Value] = k.Line
+		}
+	}
+	for _, c := range node.Content {
+		l.duplicateKeys(c)
+	}
+}
+
+// unknownFields report mapping keys which are not yaml fields of the target type
+func (l *linter) unknownFields(node *yaml.Node, t reflect.Type, severity string, what string) {
+	fields := yamlFields(t)
+	for i := 0; i < len(node.Content); i += 2 {
+		k := node.Content[i]
+		if !fields[k.Value] {
+			l.report(k, severity, "unknown %s field %q", what, k.Value)
+		}
+	}
+}
+
+func (l *linter) lintSpec(root *yaml.Node) {
+	l.unknownFields(root, reflect.TypeOf(SpecInfo{}), SeverityWarning, "spec")
+	for _, field := range []string{"name", "version", "title"} {
+		if _, v := mappingValue(root, field); v == nil || v.Value == "" {
+			l.report(root, SeverityWarning, "spec is missing %s", field)
+		}
+	}
+	_, commands := mappingValue(root, "commands")
+	if commands.Kind != yaml.SequenceNode {
+		l.report(commands, SeverityError, "commands must be a list")
+		return
+	}
+	keys := make(map[string]int)
+	ids := make(map[string]int)
+	for _, c := range commands.Content {
+		if c.Kind != yaml.MappingNode {
+			l.report(c, SeverityError, "command must be a mapping")
+			continue
+		}
+		l.unknownFields(c, reflect.TypeOf(Command{}), SeverityWarning, "command")
+		_, key := mappingValue(c, "key")
+		if key == nil || key.Value == "" {
+			l.report(c, SeverityError, "command is missing key")
+			continue
+		}
+		if line, ok := keys[key.Value]; ok {
+			l.report(key, SeverityError, "duplicate command key %q, first defined at line %d", key.Value, line)
+		} else {
+			keys[key.Value] = key.Line
+		}
+		if _, id := mappingValue(c, "id"); id != nil {
+			if line, ok := ids[id.Value]; ok {
+				l.report(id, SeverityError, "duplicate command id %q, first defined at line %d", id.Value, line)
+			} else {
+				ids[id.Value] = id.Line
+			}
+		}
+		if _, title := mappingValue(c, "title"); title == nil || title.Value == "" {
+			l.report(c, SeverityError, "command %s is missing title", key.Value)
+		}
+		if _, audit := mappingValue(c, "audit"); audit == nil || audit.Value == "" {
+			l.report(c, SeverityError, "command %s is missing audit", key.Value)
+		} else {
+			l.variables(audit)
+		}
+		if _, nodeType := mappingValue(c, "nodeType"); nodeType == nil || nodeType.Value == "" {
+			l.report(c, SeverityError, "command %s is missing nodeType", key.Value)
+		} else {
+			for _, nt := range strings.Split(nodeType.Value, ",") {
+				if !contains(nodeRoles, strings.TrimSpace(nt)) {
+					l.report(nodeType, SeverityError, "unknown node type %q, expected one of %s", nt, strings.Join(nodeRoles, "|"))
+				}
+			}
+		}
+		if _, p := mappingValue(c, "platforms"); p != nil {
+			l.platforms(p)
+		}
+		if _, probe := mappingValue(c, "probe"); probe != nil {
+			if _, ok := probes[probe.Value]; !ok {
+				l.report(probe, SeverityError, "unknown probe %q, expected one of %s", probe.Value, strings.Join(probeNames(), "|"))
+			}
+		}
+	}
+}
+
+// variables report audit variables which are not resolved by node config
+func (l *linter) variables(audit *yaml.Node) {
+	components := l.config.Node.Components()
+	for _, m := range variableRe.FindAllStringSubmatch(audit.Value, -1) {
+		params, ok := components[m[1]]
+		lookup, known := variableParams[m[2]]
+		if !ok || !known {
+			l.report(audit, SeverityError, "undefined variable %s", m[0])
+			continue
+		}
+		if len(lookup(params)) == 0 {
+			l.report(audit, SeverityError, "variable %s is not defined by node config", m[0])
+		}
+	}
+}
+
+func (l *linter) platforms(node *yaml.Node) {
+	if node.Kind != yaml.SequenceNode {
+		l.report(node, SeverityError, "platforms must be a list")
+		return
+	}
+	for _, p := range node.Content {
+		if !contains(platforms, p.Value) {
+			l.report(p, SeverityError, "unknown platform %q, expected one of %s", p.Value, strings.Join(platforms, "|"))
+		}
+	}
+}
+
+func (l *linter) lintNodeConfig(root *yaml.Node) {
+	for i := 0; i < len(root.Content); i += 2 {
+		k, v := root.Content[i], root.Content[i+1]
+		switch k.Value {
+		case "node":
+			l.nodeParams(v)
+		case "version_mapping":
+			l.versionMapping(v)
+		default:
+			l.report(k, SeverityWarning, "unknown node config field %q", k.Value)
+		}
+	}
+}
+
+func (l *linter) nodeParams(node *yaml.Node) {
+	if node.Kind != yaml.MappingNode {
+		l.report(node, SeverityError, "node must be a mapping")
+		return
+	}
+	components := l.config.Node.Components()
+	paramsFields := yamlFields(reflect.TypeOf(Params{}))
+	for i := 0; i < len(node.Content); i += 2 {
+		k, v := node.Content[i], node.Content[i+1]
+		if k.Value == "version_mapping" {
+			continue
+		}
+		if _, ok := components[k.Value]; !ok {
+			l.report(k, SeverityWarning, "unknown component %q is ignored", k.Value)
+			continue
+		}
+		if v.Kind != yaml.MappingNode {
+			l.report(v, SeverityError, "component %s must be a mapping", k.Value)
+			continue
+		}
+		for j := 0; j < len(v.Content); j += 2 {
+			field, value := v.Content[j], v.Content[j+1]
+			if !paramsFields[field.Value] {
+				l.report(field, SeverityError, "unknown %s field %q", k.Value, field.Value)
+				continue
+			}
+			paths := []*yaml.Node{value}
+			if strings.HasPrefix(field.Value, "default") {
+				if value.Kind != yaml.ScalarNode {
+					l.report(value, SeverityError, "%s.%s must be a string", k.Value, field.Value)
+					continue
+				}
+			} else {
+				if value.Kind != yaml.SequenceNode {
+					l.report(value, SeverityError, "%s.%s must be a list", k.Value, field.Value)
+					continue
+				}
+				paths = value.Content
+			}
+			if strings.HasSuffix(field.Value, "bins") {
+				continue
+			}
+			for _, p := range paths {
+				if !filepath.IsAbs(p.Value) {
+					l.report(p, SeverityError, "%s.%s path %q is not absolute", k.Value, field.Value, p.Value)
+				}
+			}
+		}
+	}
+}
+
+func (l *linter) versionMapping(node *yaml.Node) {
+	if node.Kind != yaml.MappingNode {
+		l.report(node, SeverityError, "version_mapping must be a mapping")
+		return
+	}
+	for i := 0; i < len(node.Content); i += 2 {
+		k, v := node.Content[i], node.Content[i+1]
+		if !contains(platforms, k.Value) {
+			l.report(k, SeverityError, "unknown platform %q, expected one of %s", k.Value, strings.Join(platforms, "|"))
+		}
+		if v.Kind != yaml.SequenceNode {
+			l.report(v, SeverityError, "version_mapping.%s must be a list", k.Value)
+			continue
+		}
+		for _, sv := range v.Content {
+			var specVersion SpecVersion
+			if err := sv.Decode(&specVersion); err != nil {
+				l.report(sv, SeverityError, "invalid version mapping: %s", err)
+				continue
+			}
+			l.unknownFields(sv, reflect.TypeOf(SpecVersion{}), SeverityWarning, "version mapping")
+			if specVersion.CisSpecName == "" || specVersion.CisSpecVersion == "" {
+				l.report(sv, SeverityError, "version mapping is missing spec_name or spec_version")
+			}
+			if _, err := semver.NewConstraint(fmt.Sprintf("%s %s", specVersion.Op, specVersion.Version)); err != nil {
+				l.report(sv, SeverityError, "invalid version constraint %q: %s", fmt.Sprintf("%s %s", specVersion.Op, specVersion.Version), err)
+			}
+		}
+	}
+}
+
+func (l *linter) lintMapping(root *yaml.Node) {
+	for i := 0; i < len(root.Content); i += 2 {
+		k, v := root.Content[i], root.Content[i+1]
+		if !validKubeletConfigPath(v.Value) {
+			l.report(v, SeverityError, "%s mapping %q is not a valid KubeletConfiguration field path", k.Value, v.Value)
+		}
+	}
+}
+
+// validKubeletConfigPath check that path (example: kubeletconfig.authentication.anonymous.enabled)
+// is a KubeletConfiguration field as returned by the kubelet configz api
+func validKubeletConfigPath(path string) bool {
+	if !strings.HasPrefix(path, kubeletConfigPrefix) {
+		return false
+	}
+	t := reflect.TypeOf(kubeletconfigv1beta1.KubeletConfiguration{})
+	for _, segment := range strings.Split(strings.TrimPrefix(path, kubeletConfigPrefix), ".") {
+		for t.Kind() == reflect.Ptr {
+			t = t.Elem()
+		}
+		switch t.Kind() {
+		case reflect.Map:
+			t = t.Elem()
+		case reflect.Struct:
+			f, ok := jsonField(t, segment)
+			if !ok {
+				return false
+			}
+			t = f.Type
+		default:
+			return false
+		}
+	}
+	return true
+}
+
+func jsonField(t reflect.Type, name string) (reflect.StructField, bool) {
+	for i := 0; i < t.NumField(); i++ {
+		f := t.Field(i)
+		tag := strings.Split(f.Tag.Get("json"), ",")[0]
+		if tag == "" && f.Anonymous {
+			if nested, ok := jsonField(f.Type, name); ok {
+				return nested, true
+			}
+			continue
+		}
+		if tag == name {
+			return f, true
+		}
+	}
+	return reflect.StructField{}, false
+}
+
+// yamlFields yaml field names of struct type
+func yamlFields(t reflect.Type) map[string]bool {
+	fields := make(map[string]bool)
+	for i := 0; i < t.NumField(); i++ {
+		f := t.Field(i)
+		name := strings.Split(f.Tag.Get("yaml"), ",")[0]
+		if name == "" {
+			name = strings.ToLower(f.Name)
+		}
+		fields[name] = true
+	}
+	return fields
+}
+
+// mappingValue return key and value nodes of key in a mapping node
+func mappingValue(node *yaml.Node, key string) (*yaml.Node, *yaml.Node) {
+	if node.Kind != yaml.MappingNode {
+		return nil, nil
+	}
+	for i := 0; i < len(node.Content); i += 2 {
+		if node.Content[i].Value == key {
+			return node.Content[i], node.Content[i+1]
+		}
+	}
+	return nil, nil
+}
+
+func contains(list []string, value string) bool {
+	for _, v := range list {
+		if v == value {
+			return true
+		}
+	}
+	return false
+}
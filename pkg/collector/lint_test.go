@@ -0,0 +1,109 @@
+package collector
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestLintFiles(t *testing.T) {
+	tests := []struct {
+		name    string
+		file    string
+		content string
+		want    []string
+	}{
+		{
+			name: "spec",
+			file: "spec.yaml",
+			content: `---
+version: "1.23.0"
+name: k8s-cis
+title: Node Specification for info collector
+commands:
+  - key: kubeletConfFilePermissions
+    title: kubelet.conf file permissions
+    nodeType: worker
+    audit: stat -c %a $kubelet.kubeconfig
+  - key: kubeletConfFilePermissions
+    title: kubelet.conf file permissions
+    nodeType: node
+    audit: stat -c %a $kubelet.kubeconfigs
+  - key: etcdConfFilePermissions
+    nodeType: master
+    audit: stat -c %a $etcd.kubeconfig
+    platforms:
+      - k8s
+      - openstack
+`,
+			want: []string{
+				`spec.yaml:10:10: error: duplicate command key "kubeletConfFilePermissions", first defined at line 6`,
+				`spec.yaml:12:15: error: unknown node type "node", expected one of master|worker|etcd`,
+				`spec.yaml:13:12: error: undefined variable $kubelet.kubeconfigs`,
+				`spec.yaml:14:5: error: command etcdConfFilePermissions is missing title`,
+				`spec.yaml:16:12: error: variable $etcd.kubeconfig is not defined by node config`,
+				`spec.yaml:19:9: error: unknown platform "openstack", expected one of k8s|gke|aks|eks|rke2|k3s|ocp|microk8s|kind|k0s|talos|bottlerocket`,
+			},
+		},
+		{
+			name: "node config",
+			file: "config.yaml",
+			content: `---
+node:
+  kubelet:
+    confs:
+      - /var/lib/kubelet/config.yaml
+      - var/lib/kubelet/config.yml
+    defaultconf: /var/lib/kubelet/config.yaml
+    config: /var/lib/kubelet/config.yaml
+  kubelet:
+    bins:
+      - kubelet
+version_mapping:
+  k8s:
+    - op: "=="
+      cluster_version: "1.21"
+      spec_name: k8s-cis
+      spec_version: "1.23.0"
+`,
+			want: []string{
+				`config.yaml:6:9: error: kubelet.confs path "var/lib/kubelet/config.yml" is not absolute`,
+				`config.yaml:8:5: error: unknown kubelet field "config"`,
+				`config.yaml:9:3: error: duplicate key "kubelet", first defined at line 3`,
+				`config.yaml:14:7: error: invalid version constraint "== 1.21": improper constraint: == 1.21`,
+			},
+		},
+		{
+			name: "kubelet config mapping",
+			file: "mapping.yaml",
+			content: `---
+kubeletAnonymousAuthArgumentSet: kubeletconfig.authentication.anonymous.enabled
+kubeletEventQpsArgumentSet: kubeletconfig.eventRecordQPS",
+kubeletRotateKubeletServerCertificateArgumentSet: kubeletconfig.featureGates.RotateKubeletServerCertificate
+kubeletReadOnlyPortArgumentSet: kubeletconfig.readOnlyPorts
+`,
+			want: []string{
+				`mapping.yaml:3:29: error: kubeletEventQpsArgumentSet mapping "kubeletconfig.eventRecordQPS\"," is not a valid KubeletConfiguration field path`,
+				`mapping.yaml:5:33: error: kubeletReadOnlyPortArgumentSet mapping "kubeletconfig.readOnlyPorts" is not a valid KubeletConfiguration field path`,
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := make([]string, 0)
+			for _, d := range lintFiles([]lintInput{{name: tt.file, data: []byte(tt.content)}}) {
+				got = append(got, d.String())
+			}
+			assert.Equal(t, tt.want, got)
+		})
+	}
+}
+
+func TestLintBuiltinFiles(t *testing.T) {
+	inputs, err := lintInputs(nil)
+	assert.NoError(t, err)
+	for _, d := range lintFiles(inputs) {
+		assert.NotEqual(t, SeverityError, d.Severity, d.String())
+	}
+}
@@ -0,0 +1,173 @@
+package collector
+
+import (
+	"bufio"
+	"bytes"
+	"debug/buildinfo"
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+)
+
+const (
+	osReleaseFile = "/etc/os-release"
+	microk8sSnap  = "/snap/microk8s/current/meta/snap.yaml"
+
+	evidencePath      = "path"
+	evidenceBinary    = "binary"
+	evidenceOSRelease = "os-release"
+)
+
+var (
+	gitVersionRe    = regexp.MustCompile(`v\d+\.\d+\.\d+[^\s'",()]*`)
+	ldflagVersionRe = regexp.MustCompile(`(?:version\.gitVersion|pkg/version\.Version)=(v\d+\.\d+\.\d+[^\s'"]*)`)
+
+	// kubeletBinaries kubelet or distribution binaries embedding kubelet, ordered by precedence
+	kubeletBinaries = []string{
+		"/usr/bin/kubelet",
+		"/usr/local/bin/kubelet",
+		"/var/lib/rancher/rke2/bin/kubelet",
+		"/usr/local/bin/rke2",
+		"/usr/local/bin/k3s",
+		"/usr/local/bin/k0s",
+		"/snap/microk8s/current/kubelite",
+	}
+
+	// platformPaths data directories and snap paths per distribution, ordered by precedence
+	platformPaths = []struct {
+		platform string
+		path     string
+	}{
+		{platform: rke2, path: "/var/lib/rancher/rke2"},
+		{platform: k3s, path: "/var/lib/rancher/k3s"},
+		{platform: microk8s, path: "/var/snap/microk8s"},
+		{platform: microk8s, path: "/snap/microk8s"},
+		{platform: k0s, path: "/var/lib/k0s"},
+	}
+
+	// osReleasePlatforms /etc/os-release ID per platform
+	osReleasePlatforms = map[string]string{
+		"talos":        talos,
+		"bottlerocket": bottlerocket,
+		"rhcos":        ocp,
+		"cos":          gke,
+	}
+)
+
+// localPlatform detect platform and kubernetes version from node local signals when the api server is not reachable:
+// kubelet binary build info or --version, k3s/rke2/k0s data directories, microk8s snap and /etc/os-release.
+// root is the node file system root, empty platform is returned when nothing is found
+func localPlatform(root string, sh Shell) Platform {
+	version, versionEvidence := localVersion(root, sh)
+	candidates := make([][2]string, 0)
+	if version != "" {
+		if name, evidence := detectPlatform(nil, version); name != native {
+			for _, e := range evidence {
+				candidates = append(candidates, [2]string{name, e})
+			}
+		}
+	}
+	for _, p := range platformPaths {
+		if _, err := os.Stat(filepath.Join(root, p.path)); err == nil {
+			candidates = append(candidates, [2]string{p.platform, fmt.Sprintf("%s %s", evidencePath, p.path)})
+		}
+	}
+	if id := osRelease(root)["ID"]; id != "" {
+		if name, ok := osReleasePlatforms[id]; ok {
+			candidates = append(candidates, [2]string{name, fmt.Sprintf("%s ID=%s", evidenceOSRelease, id)})
+		}
+	}
+	if len(candidates) == 0 && version == "" {
+		return Platform{}
+	}
+	platform := Platform{Name: native, Version: majorVersion(version), Evidence: make([]string, 0)}
+	if len(candidates) > 0 {
+		platform.Name = candidates[0][0]
+	}
+	if versionEvidence != "" {
+		platform.Evidence = append(platform.Evidence, versionEvidence)
+	}
+	for _, c := range candidates {
+		if c[0] == platform.Name {
+			platform.Evidence = append(platform.Evidence, c[1])
+		}
+	}
+	return platform
+}
+
+// localVersion kubernetes git version from kubelet binaries build info, microk8s snap or binaries --version output
+func localVersion(root string, sh Shell) (string, string) {
+	for _, bin := range kubeletBinaries {
+		if version := binaryVersion(filepath.Join(root, bin)); version != "" {
+			return version, fmt.Sprintf("%s %s %s", evidenceBinary, bin, version)
+		}
+	}
+	if data, err := os.ReadFile(filepath.Join(root, microk8sSnap)); err == nil {
+		if version := snapVersion(data); version != "" {
+			return version, fmt.Sprintf("%s %s %s", evidencePath, microk8sSnap, version)
+		}
+	}
+	for _, bin := range kubeletBinaries {
+		path := filepath.Join(root, bin)
+		if _, err := os.Stat(path); err != nil {
+			continue
+		}
+		output, err := sh.Execute(fmt.Sprintf("%s --version 2>/dev/null", path))
+		if err != nil {
+			continue
+		}
+		if version := gitVersionRe.FindString(output); version != "" {
+			return version, fmt.Sprintf("%s %s --version %s", evidenceBinary, bin, version)
+		}
+	}
+	return "", ""
+}
+
+// binaryVersion kubernetes git version from go binary build info -ldflags, example: -X 'k8s.io/component-base/version.gitVersion=v1.29.1'
+func binaryVersion(path string) string {
+	info, err := buildinfo.ReadFile(path)
+	if err != nil {
+		return ""
+	}
+	for _, s := range info.Settings {
+		if s.Key != "-ldflags" {
+			continue
+		}
+		if m := ldflagVersionRe.FindStringSubmatch(s.Value); len(m) == 2 {
+			return m[1]
+		}
+	}
+	return ""
+}
+
+// snapVersion version field of snap.yaml
+func snapVersion(data []byte) string {
+	scanner := bufio.NewScanner(bytes.NewReader(data))
+	for scanner.Scan() {
+		key, value, ok := strings.Cut(scanner.Text(), ":")
+		if ok && strings.TrimSpace(key) == "version" {
+			return gitVersionRe.FindString(value)
+		}
+	}
+	return ""
+}
+
+// osRelease parse /etc/os-release key=value pairs
+func osRelease(root string) map[string]string {
+	release := make(map[string]string)
+	data, err := os.ReadFile(filepath.Join(root, osReleaseFile))
+	if err != nil {
+		return release
+	}
+	scanner := bufio.NewScanner(bytes.NewReader(data))
+	for scanner.Scan() {
+		key, value, ok := strings.Cut(strings.TrimSpace(scanner.Text()), "=")
+		if !ok || strings.HasPrefix(key, "#") {
+			continue
+		}
+		release[key] = strings.Trim(value, `"'`)
+	}
+	return release
+}
@@ -0,0 +1,149 @@
+package collector
+
+import (
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestLocalPlatform(t *testing.T) {
+	tests := []struct {
+		name  string
+		files map[string]string
+		shell func(root string) fakeShell
+		want  Platform
+	}{
+		{
+			name:  "nothing found",
+			files: map[string]string{},
+			want:  Platform{},
+		},
+		{
+			name: "k3s binary version and data dir",
+			files: map[string]string{
+				"/usr/local/bin/k3s":                "",
+				"/var/lib/rancher/k3s/server/token": "",
+			},
+			shell: func(root string) fakeShell {
+				return fakeShell{filepath.Join(root, "/usr/local/bin/k3s") + " --version": "k3s version v1.27.3+k3s1 (fe9604ca)"}
+			},
+			want: Platform{Name: k3s, Version: "1.27", Evidence: []string{
+				"binary /usr/local/bin/k3s --version v1.27.3+k3s1",
+				"version v1.27.3+k3s1",
+				"path /var/lib/rancher/k3s",
+			}},
+		},
+		{
+			name: "microk8s snap",
+			files: map[string]string{
+				"/snap/microk8s/current/meta/snap.yaml": "name: microk8s\nversion: v1.29.1\nsummary: Kubernetes for workstations and appliances\n",
+			},
+			want: Platform{Name: microk8s, Version: "1.29", Evidence: []string{
+				"path /snap/microk8s/current/meta/snap.yaml v1.29.1",
+				"path /snap/microk8s",
+			}},
+		},
+		{
+			name: "bottlerocket os release",
+			files: map[string]string{
+				"/etc/os-release": "NAME=Bottlerocket\nID=bottlerocket\nVERSION_ID=1.19.2\n",
+			},
+			want: Platform{Name: bottlerocket, Evidence: []string{"os-release ID=bottlerocket"}},
+		},
+		{
+			name: "kubeadm kubelet",
+			files: map[string]string{
+				"/usr/bin/kubelet": "",
+				"/etc/os-release":  "PRETTY_NAME=\"Ubuntu 22.04.4 LTS\"\nID=ubuntu\n",
+			},
+			shell: func(root string) fakeShell {
+				return fakeShell{filepath.Join(root, "/usr/bin/kubelet") + " --version": "Kubernetes v1.30.2"}
+			},
+			want: Platform{Name: native, Version: "1.30", Evidence: []string{"binary /usr/bin/kubelet --version v1.30.2"}},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			root := writeFiles(t, tt.files)
+			sh := fakeShell{}
+			if tt.shell != nil {
+				sh = tt.shell(root)
+			}
+			assert.Equal(t, tt.want, localPlatform(root, sh))
+		})
+	}
+}
+
+func TestLocalPlatformHostLayout(t *testing.T) {
+	tests := []struct {
+		name  string
+		files map[string]string
+		want  Platform
+	}{
+		{
+			name: "k3s server",
+			files: map[string]string{
+				"/etc/os-release":                                  "PRETTY_NAME=\"Ubuntu 22.04.4 LTS\"\nID=ubuntu\n",
+				"/etc/rancher/k3s/k3s.yaml":                        "apiVersion: v1\nkind: Config\n",
+				"/usr/local/bin/k3s":                               "",
+				"/var/lib/rancher/k3s/server/token":                "K10",
+				"/var/lib/rancher/k3s/agent/etc/containerd/a.toml": "",
+				"/var/lib/rancher/k3s/agent/kubelet.kubeconfig":    "apiVersion: v1\nkind: Config\n",
+			},
+			want: Platform{Name: k3s, Evidence: []string{"path /var/lib/rancher/k3s"}},
+		},
+		{
+			name: "rke2 agent",
+			files: map[string]string{
+				"/etc/os-release":                                "NAME=\"Rocky Linux\"\nID=\"rocky\"\nVERSION_ID=\"9.3\"\n",
+				"/etc/rancher/rke2/config.yaml":                  "server: https://10.0.0.1:9345\n",
+				"/usr/local/bin/rke2":                            "",
+				"/var/lib/rancher/rke2/bin/kubelet":              "",
+				"/var/lib/rancher/rke2/agent/kubelet.kubeconfig": "apiVersion: v1\nkind: Config\n",
+			},
+			want: Platform{Name: rke2, Evidence: []string{"path /var/lib/rancher/rke2"}},
+		},
+		{
+			name: "microk8s",
+			files: map[string]string{
+				"/etc/os-release":                               "ID=ubuntu\n",
+				"/snap/microk8s/current/meta/snap.yaml":         "name: microk8s\nversion: v1.28.3\n",
+				"/var/snap/microk8s/current/args/kubelet":       "--kubeconfig=${SNAP_DATA}/credentials/kubelet.config\n",
+				"/var/snap/microk8s/current/credentials/ca.crt": "",
+			},
+			want: Platform{Name: microk8s, Version: "1.28", Evidence: []string{
+				"path /snap/microk8s/current/meta/snap.yaml v1.28.3",
+				"path /var/snap/microk8s",
+				"path /snap/microk8s",
+			}},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			// binaries are empty files, no --version output: detection rely on the host files only
+			assert.Equal(t, tt.want, localPlatform(writeFiles(t, tt.files), fakeShell{}))
+		})
+	}
+}
+
+func TestMajorVersion(t *testing.T) {
+	assert.Equal(t, "1.29", majorVersion("v1.29.1+k3s1"))
+	assert.Equal(t, "1.23", majorVersion("1.23.2"))
+	assert.Equal(t, "", majorVersion("unknown"))
+	assert.Equal(t, "", majorVersion(""))
+}
+
+func TestSpecVersionsByName(t *testing.T) {
+	versionMapping := map[string][]SpecVersion{
+		native: {{Op: ">=", Version: "1.21", CisSpecName: "k8s-cis", CisSpecVersion: "1.23.0"}},
+		rke2:   {{Op: ">=", Version: "1.21", CisSpecName: "rke2-cis", CisSpecVersion: "1.24"}},
+		k3s:    {{Op: "<", Version: "1.21", CisSpecName: "k8s-cis", CisSpecVersion: "1.20.0"}},
+	}
+	got := specVersionsByName(versionMapping, "k8s-cis")
+	assert.Equal(t, []SpecVersion{
+		{Op: "<", Version: "1.21", CisSpecName: "k8s-cis", CisSpecVersion: "1.20.0"},
+		{Op: ">=", Version: "1.21", CisSpecName: "k8s-cis", CisSpecVersion: "1.23.0"},
+	}, got)
+	assert.Equal(t, "k8s-cis-1.23.0", specByPlatfromVersion(Platform{Name: "unknown", Version: "1.29"}, map[string][]SpecVersion{"unknown": got}))
+}
@@ -0,0 +1,43 @@
+package collector
+
+import (
+	"fmt"
+	"io"
+	"log/slog"
+	"os"
+	"strings"
+
+	"github.com/spf13/cobra"
+)
+
+const (
+	logFormatText = "text"
+	logFormatJSON = "json"
+)
+
+// SetupLogging set the default logger from log-level and log-format flags, logs are written to stderr so they
+// never mix with the collected output
+func SetupLogging(cmd *cobra.Command) error {
+	logger, err := newLogger(os.Stderr, cmd.Flag("log-level").Value.String(), cmd.Flag("log-format").Value.String())
+	if err != nil {
+		return err
+	}
+	slog.SetDefault(logger)
+	return nil
+}
+
+// newLogger structured logger of level debug|info|warn|error and format text|json
+func newLogger(w io.Writer, level string, format string) (*slog.Logger, error) {
+	var l slog.Level
+	if err := l.UnmarshalText([]byte(level)); err != nil {
+		return nil, fmt.Errorf("invalid log level %q, one of debug|info|warn|error", level)
+	}
+	opts := &slog.HandlerOptions{Level: l}
+	switch strings.ToLower(format) {
+	case logFormatText:
+		return slog.New(slog.NewTextHandler(w, opts)), nil
+	case logFormatJSON:
+		return slog.New(slog.NewJSONHandler(w, opts)), nil
+	}
+	return nil, fmt.Errorf("invalid log format %q, one of text|json", format)
+}
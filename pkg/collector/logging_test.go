@@ -0,0 +1,77 @@
+package collector
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"log/slog"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestNewLogger(t *testing.T) {
+	tests := []struct {
+		name    string
+		level   string
+		format  string
+		want    string
+		wantErr string
+	}{
+		{name: "text info", level: "info", format: "text", want: "level=INFO msg=info"},
+		{name: "json debug", level: "debug", format: "json", want: `{"level":"DEBUG","msg":"debug"}`},
+		{name: "upper case", level: "WARN", format: "JSON", want: `{"level":"WARN","msg":"warn"}`},
+		{name: "invalid level", level: "trace", format: "text", wantErr: `invalid log level "trace", one of debug|info|warn|error`},
+		{name: "invalid format", level: "info", format: "yaml", wantErr: `invalid log format "yaml", one of text|json`},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			buff := new(bytes.Buffer)
+			logger, err := newLogger(buff, tt.level, tt.format)
+			if tt.wantErr != "" {
+				assert.EqualError(t, err, tt.wantErr)
+				return
+			}
+			assert.NoError(t, err)
+			logger = slog.New(timelessHandler{logger.Handler()})
+			logger.Debug("debug")
+			logger.Info("info")
+			logger.Warn("warn")
+			first, _, _ := strings.Cut(buff.String(), "\n")
+			assert.Equal(t, tt.want, first)
+		})
+	}
+}
+
+func TestExecuteCommandsDebugTrace(t *testing.T) {
+	buff := new(bytes.Buffer)
+	logger, err := newLogger(buff, "debug", "json")
+	assert.NoError(t, err)
+	defer slog.SetDefault(slog.Default())
+	slog.SetDefault(logger)
+
+	_, err = ExecuteCommands(fakeShell{"stat -c %a /etc/kubernetes/admin.conf": "600"}, ProbeContext{}, []Command{
+		{Key: "adminConfFilePermissions", Audit: "stat -c %a /etc/kubernetes/admin.conf"},
+	})
+	assert.NoError(t, err)
+	var trace map[string]interface{}
+	assert.NoError(t, json.Unmarshal(buff.Bytes(), &trace))
+	assert.Equal(t, "command executed", trace["msg"])
+	assert.Equal(t, "adminConfFilePermissions", trace["key"])
+	assert.Equal(t, "stat -c %a /etc/kubernetes/admin.conf", trace["command"])
+	assert.Equal(t, float64(0), trace["exitCode"])
+	assert.Equal(t, float64(3), trace["outputSize"])
+	assert.Contains(t, trace, "duration")
+}
+
+// timelessHandler handler dropping the record time to compare output
+type timelessHandler struct {
+	slog.Handler
+}
+
+func (h timelessHandler) Handle(ctx context.Context, r slog.Record) error {
+	r.Time = time.Time{}
+	return h.Handler.Handle(ctx, r)
+}
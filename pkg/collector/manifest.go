@@ -0,0 +1,413 @@
+package collector
+
+import (
+	"encoding/base64"
+	"fmt"
+	"io"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strings"
+
+	"github.com/spf13/cobra"
+	"gopkg.in/yaml.v3"
+	appsv1 "k8s.io/api/apps/v1"
+	batchv1 "k8s.io/api/batch/v1"
+	corev1 "k8s.io/api/core/v1"
+	rbacv1 "k8s.io/api/rbac/v1"
+	v1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	k8syaml "sigs.k8s.io/yaml"
+)
+
+const (
+	// ManifestKindJob generate a Job running node-collector once
+	ManifestKindJob = "job"
+	// ManifestKindDaemonSet generate a DaemonSet running node-collector on every node
+	ManifestKindDaemonSet = "daemonset"
+
+	pauseImage = "registry.k8s.io/pause:3.9"
+
+	// hostRootMountPath mount path of the node root file system, host facts and platform detection read host files under it
+	hostRootMountPath = "/host"
+	hostRootVolume    = "host-root"
+)
+
+var (
+	// auditPathRe match absolute paths referenced in audit commands
+	auditPathRe = regexp.MustCompile(`(?:^|[\s'"=(:>,])((?:/[\w.\-]+)+)`)
+	// hostPIDRe match audit commands which inspect host processes
+	hostPIDRe = regexp.MustCompile(`(?:^|[\s|;(` + "`" + `])(?:ps|pgrep|pidof)\s|/proc/`)
+	// processInspectRe match audit commands which read host processes environment, root or mounts
+	processInspectRe = regexp.MustCompile(`/proc/(?:\d+|\$\([^)]*\)|\$\w+)/(?:environ|root|cwd|exe|fd|mountinfo|mounts)\b`)
+	// configVarRe match node config variables substituted by host paths, example: $kubelet.confs
+	configVarRe = regexp.MustCompile(`\$\w+\.\w+`)
+	// processInspectProbes probes which read host processes state, example: etcd probe read /proc/1/mountinfo
+	processInspectProbes = map[string]bool{
+		ProbeEtcd: true,
+	}
+
+	excludedHostPaths = []string{"/dev", "/proc", "/sys", "/tmp"}
+)
+
+type manifestOptions struct {
+	kind      string
+	name      string
+	namespace string
+	image     string
+	nodeName  string
+	hostPID   bool
+	// capabilities added to the collector container, all others are dropped
+	capabilities []corev1.Capability
+	hostPaths    []string
+	args         []string
+}
+
+// GenerateManifest generate node-collector RBAC and Job / DaemonSet manifests
+// with host path volumes derived from node config and spec commands
+func GenerateManifest(cmd *cobra.Command) error {
+	payloads, err := newPayloadLoader(cmd)
+	if err != nil {
+		return err
+	}
+	nodeFileconfig, err := payloadOrDefault(payloads, cmd, "node-config", func() ([]byte, error) {
+		return defaultNodeConfig, nil
+	})
+	if err != nil {
+		return err
+	}
+	nodeCommands, err := payloadOrDefault(payloads, cmd, "node-commands", func() ([]byte, error) {
+		return loadSpec(specName(cmd.Flag("spec-name").Value.String(), cmd.Flag("spec-version").Value.String()))
+	})
+	if err != nil {
+		return err
+	}
+	kubeletConfigMapping, err := payloadOrDefault(payloads, cmd, "kubelet-config-mapping", func() ([]byte, error) {
+		return defaultKubeletConfigMapping, nil
+	})
+	if err != nil {
+		return err
+	}
+	config, err := parseConfigParams(nodeFileconfig)
+	if err != nil {
+		return err
+	}
+	var spec SpecInfo
+	err = yaml.Unmarshal(nodeCommands, &spec)
+	if err != nil {
+		return err
+	}
+	args, err := manifestArgs(cmd, map[string][]byte{
+		"node-config":            nodeFileconfig,
+		"node-commands":          nodeCommands,
+		"kubelet-config-mapping": kubeletConfigMapping,
+	})
+	if err != nil {
+		return err
+	}
+	opts := manifestOptions{
+		kind:         cmd.Flag("kind").Value.String(),
+		name:         cmd.Flag("name").Value.String(),
+		namespace:    cmd.Flag("namespace").Value.String(),
+		image:        cmd.Flag("image").Value.String(),
+		nodeName:     cmd.Flag("node").Value.String(),
+		hostPID:      requiresHostPID(config, spec.Commands),
+		capabilities: requiredCapabilities(spec.Commands),
+		hostPaths:    manifestHostPaths(config, spec.Commands),
+		args:         args,
+	}
+	objects, err := buildManifests(opts)
+	if err != nil {
+		return err
+	}
+	return writeManifests(objects, cmd.OutOrStdout())
+}
+
+// manifestArgs build node-collector container args with embedded payloads, their signatures and public keys
+func manifestArgs(cmd *cobra.Command, payloads map[string][]byte) ([]string, error) {
+	args := []string{"k8s", "--node", "$(NODE_NAME)", "--host-root", hostRootMountPath}
+	for _, name := range []string{"node-config", "node-commands", "kubelet-config-mapping"} {
+		encoded, err := EncodeWithCompression(payloads[name], CompressionBzip2)
+		if err != nil {
+			return nil, err
+		}
+		args = append(args, fmt.Sprintf("--%s", name), encoded)
+		signatureFlag := fmt.Sprintf("%s-signature", name)
+		if signature := cmd.Flag(signatureFlag).Value.String(); signature != "" {
+			args = append(args, fmt.Sprintf("--%s", signatureFlag), signature)
+		}
+	}
+	verifier, err := payloadVerifier(cmd)
+	if err != nil {
+		return nil, err
+	}
+	for _, key := range verifier.keys {
+		args = append(args, "--public-key", base64.StdEncoding.EncodeToString(key))
+	}
+	if verifier.required {
+		args = append(args, "--require-signature")
+	}
+	return args, nil
+}
+
+// manifestHostPaths compute the host paths to mount from node config params and absolute paths
+// referenced by spec commands, nested paths are covered by their parent mount
+func manifestHostPaths(config *Config, commands []Command) []string {
+	candidates := make([]string, 0)
+	for _, params := range config.Node.Components() {
+		candidates = append(candidates, params.Paths()...)
+	}
+	for _, c := range commands {
+		candidates = append(candidates, auditPaths(c.Audit)...)
+	}
+	roots := make(map[string]bool)
+	for _, path := range candidates {
+		if root := mountRoot(path); root != "" {
+			roots[root] = true
+		}
+	}
+	paths := make([]string, 0, len(roots))
+	for root := range roots {
+		paths = append(paths, root)
+	}
+	sort.Strings(paths)
+	hostPaths := make([]string, 0, len(paths))
+	for _, path := range paths {
+		if len(hostPaths) > 0 && isSubPath(hostPaths[len(hostPaths)-1], path) {
+			continue
+		}
+		hostPaths = append(hostPaths, path)
+	}
+	return hostPaths
+}
+
+// auditPaths extract absolute paths referenced by an audit command, a path component
+// with glob pattern is dropped
+func auditPaths(audit string) []string {
+	paths := make([]string, 0)
+	for _, loc := range auditPathRe.FindAllStringSubmatchIndex(audit, -1) {
+		path := audit[loc[2]:loc[3]]
+		if loc[3] < len(audit) && strings.ContainsRune("*?[", rune(audit[loc[3]])) {
+			path = filepath.Dir(path)
+		}
+		if path != "/" {
+			paths = append(paths, path)
+		}
+	}
+	return paths
+}
+
+// mountRoot return the directory to mount in order to cover path, example:
+// /etc/kubernetes/manifests/kube-apiserver.yaml -> /etc/kubernetes and /var/lib/kubelet/config.yaml -> /var/lib/kubelet
+func mountRoot(path string) string {
+	path = filepath.Clean(path)
+	if !filepath.IsAbs(path) {
+		return ""
+	}
+	for _, excluded := range excludedHostPaths {
+		if isSubPath(excluded, path) {
+			return ""
+		}
+	}
+	parts := strings.Split(strings.TrimPrefix(path, "/"), "/")
+	depth := 2
+	if len(parts) > 1 && (parts[0] == "var" || parts[0] == "usr") && (parts[1] == "lib" || parts[1] == "snap" || parts[1] == "local") {
+		depth = 3
+	}
+	if len(parts) < depth {
+		return ""
+	}
+	return "/" + strings.Join(parts[:depth], "/")
+}
+
+func isSubPath(parent string, path string) bool {
+	return path == parent || strings.HasPrefix(path, strings.TrimSuffix(parent, "/")+"/")
+}
+
+// requiresHostPID check if binaries lookup or spec commands inspect host processes
+func requiresHostPID(config *Config, commands []Command) bool {
+	for _, params := range config.Node.Components() {
+		if len(params.Binaries) > 0 {
+			return true
+		}
+	}
+	for _, c := range commands {
+		if hostPIDRe.MatchString(c.Audit) {
+			return true
+		}
+	}
+	return false
+}
+
+// requiredCapabilities capabilities spec commands need as root without any other capability: DAC_READ_SEARCH
+// to read host files owned by other users (etcd data dir, kubelet pki) and SYS_PTRACE to read host processes
+// environment, root or mounts
+func requiredCapabilities(commands []Command) []corev1.Capability {
+	var readFiles, inspectProcesses bool
+	for _, c := range commands {
+		switch {
+		case c.Probe == ProbeSysctl:
+		case c.Probe != "":
+			readFiles = true
+			inspectProcesses = inspectProcesses || processInspectProbes[c.Probe]
+		default:
+			inspectProcesses = inspectProcesses || processInspectRe.MatchString(c.Audit)
+			readFiles = readFiles || configVarRe.MatchString(c.Audit) || len(manifestAuditPaths(c.Audit)) > 0
+		}
+	}
+	capabilities := make([]corev1.Capability, 0)
+	if readFiles {
+		capabilities = append(capabilities, "DAC_READ_SEARCH")
+	}
+	if inspectProcesses {
+		capabilities = append(capabilities, "SYS_PTRACE")
+	}
+	return capabilities
+}
+
+// manifestAuditPaths audit paths which are mounted from the host
+func manifestAuditPaths(audit string) []string {
+	paths := make([]string, 0)
+	for _, path := range auditPaths(audit) {
+		if mountRoot(path) != "" {
+			paths = append(paths, path)
+		}
+	}
+	return paths
+}
+
+func volumeName(path string) string {
+	name := strings.ToLower(strings.Trim(strings.NewReplacer("/", "-", ".", "-", "_", "-").Replace(path), "-"))
+	if len(name) > 63 {
+		name = strings.TrimRight(name[:63], "-")
+	}
+	return name
+}
+
+func buildManifests(opts manifestOptions) ([]runtime.Object, error) {
+	meta := v1.ObjectMeta{Name: opts.name, Namespace: opts.namespace, Labels: map[string]string{"app": opts.name}}
+	clusterMeta := v1.ObjectMeta{Name: opts.name, Labels: map[string]string{"app": opts.name}}
+	objects := []runtime.Object{
+		&corev1.ServiceAccount{
+			TypeMeta:   v1.TypeMeta{APIVersion: "v1", Kind: "ServiceAccount"},
+			ObjectMeta: meta,
+		},
+		&rbacv1.ClusterRole{
+			TypeMeta:   v1.TypeMeta{APIVersion: "rbac.authorization.k8s.io/v1", Kind: "ClusterRole"},
+			ObjectMeta: clusterMeta,
+			Rules: []rbacv1.PolicyRule{
+				{APIGroups: []string{""}, Resources: []string{"nodes"}, Verbs: []string{"get", "list"}},
+				{APIGroups: []string{""}, Resources: []string{"nodes/proxy"}, Verbs: []string{"get"}},
+				{APIGroups: []string{"config.openshift.io"}, Resources: []string{"clusterversions"}, Verbs: []string{"get", "list"}},
+			},
+		},
+		&rbacv1.ClusterRoleBinding{
+			TypeMeta:   v1.TypeMeta{APIVersion: "rbac.authorization.k8s.io/v1", Kind: "ClusterRoleBinding"},
+			ObjectMeta: clusterMeta,
+			RoleRef:    rbacv1.RoleRef{APIGroup: "rbac.authorization.k8s.io", Kind: "ClusterRole", Name: opts.name},
+			Subjects:   []rbacv1.Subject{{Kind: "ServiceAccount", Name: opts.name, Namespace: opts.namespace}},
+		},
+	}
+	podTemplate := corev1.PodTemplateSpec{
+		ObjectMeta: v1.ObjectMeta{Labels: map[string]string{"app": opts.name}},
+		Spec:       podSpec(opts),
+	}
+	switch opts.kind {
+	case ManifestKindJob:
+		podTemplate.Spec.RestartPolicy = corev1.RestartPolicyNever
+		podTemplate.Spec.NodeName = opts.nodeName
+		objects = append(objects, &batchv1.Job{
+			TypeMeta:   v1.TypeMeta{APIVersion: "batch/v1", Kind: "Job"},
+			ObjectMeta: meta,
+			Spec:       batchv1.JobSpec{Template: podTemplate},
+		})
+	case ManifestKindDaemonSet:
+		// collect once per node in an init container and keep the pod alive with pause container
+		podTemplate.Spec.InitContainers = podTemplate.Spec.Containers
+		podTemplate.Spec.Containers = []corev1.Container{{
+			Name:            "pause",
+			Image:           pauseImage,
+			SecurityContext: containerSecurityContext(nil),
+		}}
+		objects = append(objects, &appsv1.DaemonSet{
+			TypeMeta:   v1.TypeMeta{APIVersion: "apps/v1", Kind: "DaemonSet"},
+			ObjectMeta: meta,
+			Spec: appsv1.DaemonSetSpec{
+				Selector: &v1.LabelSelector{MatchLabels: map[string]string{"app": opts.name}},
+				Template: podTemplate,
+			},
+		})
+	default:
+		return nil, fmt.Errorf("manifest kind %s is not supported", opts.kind)
+	}
+	return objects, nil
+}
+
+func podSpec(opts manifestOptions) corev1.PodSpec {
+	volumes := []corev1.Volume{{
+		Name:         hostRootVolume,
+		VolumeSource: corev1.VolumeSource{HostPath: &corev1.HostPathVolumeSource{Path: "/"}},
+	}}
+	mounts := []corev1.VolumeMount{{Name: hostRootVolume, MountPath: hostRootMountPath, ReadOnly: true}}
+	for _, path := range opts.hostPaths {
+		name := volumeName(path)
+		volumes = append(volumes, corev1.Volume{
+			Name:         name,
+			VolumeSource: corev1.VolumeSource{HostPath: &corev1.HostPathVolumeSource{Path: path}},
+		})
+		mounts = append(mounts, corev1.VolumeMount{Name: name, MountPath: path, ReadOnly: true})
+	}
+	return corev1.PodSpec{
+		ServiceAccountName:           opts.name,
+		AutomountServiceAccountToken: boolPtr(true),
+		HostPID:                      opts.hostPID,
+		Tolerations:                  []corev1.Toleration{{Operator: corev1.TolerationOpExists}},
+		SecurityContext: &corev1.PodSecurityContext{
+			RunAsUser:      int64Ptr(0),
+			RunAsGroup:     int64Ptr(0),
+			SeccompProfile: &corev1.SeccompProfile{Type: corev1.SeccompProfileTypeRuntimeDefault},
+		},
+		Containers: []corev1.Container{{
+			Name:    opts.name,
+			Image:   opts.image,
+			Command: []string{"node-collector"},
+			Args:    opts.args,
+			Env: []corev1.EnvVar{{
+				Name:      "NODE_NAME",
+				ValueFrom: &corev1.EnvVarSource{FieldRef: &corev1.ObjectFieldSelector{FieldPath: "spec.nodeName"}},
+			}},
+			SecurityContext: containerSecurityContext(opts.capabilities),
+			VolumeMounts:    mounts,
+		}},
+		Volumes: volumes,
+	}
+}
+
+// containerSecurityContext non privileged context dropping all capabilities but the added ones
+func containerSecurityContext(capabilities []corev1.Capability) *corev1.SecurityContext {
+	return &corev1.SecurityContext{
+		AllowPrivilegeEscalation: boolPtr(false),
+		Capabilities:             &corev1.Capabilities{Add: capabilities, Drop: []corev1.Capability{"all"}},
+		Privileged:               boolPtr(false),
+		ReadOnlyRootFilesystem:   boolPtr(true),
+	}
+}
+
+func writeManifests(objects []runtime.Object, writer io.Writer) error {
+	for _, o := range objects {
+		data, err := k8syaml.Marshal(o)
+		if err != nil {
+			return err
+		}
+		fmt.Fprintf(writer, "---\n%s", string(data))
+	}
+	return nil
+}
+
+func boolPtr(b bool) *bool {
+	return &b
+}
+
+func int64Ptr(i int64) *int64 {
+	return &i
+}
@@ -0,0 +1,171 @@
+package collector
+
+import (
+	"testing"
+
+	"github.com/spf13/cobra"
+	"github.com/stretchr/testify/assert"
+	corev1 "k8s.io/api/core/v1"
+)
+
+func TestAuditPaths(t *testing.T) {
+	tests := []struct {
+		name  string
+		audit string
+		want  []string
+	}{
+		{
+			name:  "single file",
+			audit: "stat -c %a /etc/kubernetes/admin.conf",
+			want:  []string{"/etc/kubernetes/admin.conf"},
+		},
+		{
+			name:  "glob component",
+			audit: "stat -c %a /etc/cni/net.d/*.conf 2>/dev/null",
+			want:  []string{"/etc/cni/net.d", "/dev/null"},
+		},
+		{
+			name:  "glob root component",
+			audit: "stat -c %a /*/cni/*",
+			want:  []string{},
+		},
+		{
+			name:  "variables only",
+			audit: "stat -c %U:%G $kubelet.confs",
+			want:  []string{},
+		},
+		{
+			name:  "awk script",
+			audit: `ls -R $kubelet.cafile | awk '/:$/&&f{s=$0;f=0}/:$/&&!f{sub(/:$/,"");s=$0;f=1;next}NF&&f{print s"/"$0 }'`,
+			want:  []string{},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.want, auditPaths(tt.audit))
+		})
+	}
+}
+
+func TestManifestHostPaths(t *testing.T) {
+	config := &Config{Node: NodeParams{
+		APIserver: Params{
+			Config:        []string{"/etc/kubernetes/manifests/kube-apiserver.yaml", "/var/lib/rancher/rke2/agent/pod-manifests/kube-apiserver.yaml"},
+			DefaultConfig: "/etc/kubernetes/manifests/kube-apiserver.yaml",
+		},
+		Etcd: Params{
+			DataDirs: []string{"/var/lib/etcd/default.etcd"},
+		},
+		KubeLet: Params{
+			Binaries: []string{"kubelet"},
+			Config:   []string{"/var/lib/kubelet/config.yaml", "/etc/default/kubelet"},
+			Services: []string{"/etc/systemd/system/kubelet.service.d/10-kubeadm.conf"},
+		},
+	}}
+	commands := []Command{
+		{Key: "adminConfFilePermissions", Audit: "stat -c %a /etc/kubernetes/admin.conf"},
+		{Key: "containerNetworkInterfaceFilePermissions", Audit: "stat -c %a /etc/cni/net.d/* 2>/dev/null"},
+	}
+	want := []string{
+		"/etc/cni",
+		"/etc/default",
+		"/etc/kubernetes",
+		"/etc/systemd",
+		"/var/lib/etcd",
+		"/var/lib/kubelet",
+		"/var/lib/rancher",
+	}
+	assert.Equal(t, want, manifestHostPaths(config, commands))
+	assert.True(t, requiresHostPID(config, commands))
+	assert.False(t, requiresHostPID(&Config{}, commands))
+	assert.True(t, requiresHostPID(&Config{}, []Command{{Audit: "ps -ef | grep kubelet"}}))
+}
+
+func TestBuildManifests(t *testing.T) {
+	opts := manifestOptions{
+		name:      "node-collector",
+		namespace: "default",
+		image:     "node-collector:dev",
+		hostPID:   true,
+		hostPaths: []string{"/etc/kubernetes", "/var/lib/kubelet"},
+		args:      []string{"k8s"},
+	}
+	for _, kind := range []string{ManifestKindJob, ManifestKindDaemonSet} {
+		opts.kind = kind
+		objects, err := buildManifests(opts)
+		assert.NoError(t, err)
+		assert.Len(t, objects, 4)
+	}
+	spec := podSpec(opts)
+	assert.Equal(t, corev1.Volume{Name: hostRootVolume, VolumeSource: corev1.VolumeSource{HostPath: &corev1.HostPathVolumeSource{Path: "/"}}}, spec.Volumes[0])
+	assert.Equal(t, corev1.VolumeMount{Name: hostRootVolume, MountPath: hostRootMountPath, ReadOnly: true}, spec.Containers[0].VolumeMounts[0])
+	opts.kind = "deployment"
+	_, err := buildManifests(opts)
+	assert.Error(t, err)
+	assert.Equal(t, "var-lib-kubelet", volumeName("/var/lib/kubelet"))
+}
+
+func TestManifestArgs(t *testing.T) {
+	cmd := &cobra.Command{}
+	for _, name := range []string{"node-config-signature", "node-commands-signature", "kubelet-config-mapping-signature"} {
+		cmd.Flags().String(name, "", "")
+	}
+	cmd.Flags().StringSlice("public-key", []string{}, "")
+	cmd.Flags().StringSlice("public-key-file", []string{}, "")
+	cmd.Flags().Bool("require-signature", false, "")
+	args, err := manifestArgs(cmd, map[string][]byte{})
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"k8s", "--node", "$(NODE_NAME)", "--host-root", hostRootMountPath}, args[:5])
+}
+
+func TestRequiredCapabilities(t *testing.T) {
+	tests := []struct {
+		name     string
+		commands []Command
+		want     *corev1.SecurityContext
+	}{
+		{
+			name:     "kernel parameters only",
+			commands: []Command{{Key: "kernelIPForward", Probe: ProbeSysctl, Audit: "net.ipv4.ip_forward"}},
+			want: &corev1.SecurityContext{
+				AllowPrivilegeEscalation: boolPtr(false),
+				Capabilities:             &corev1.Capabilities{Add: []corev1.Capability{}, Drop: []corev1.Capability{"all"}},
+				Privileged:               boolPtr(false),
+				ReadOnlyRootFilesystem:   boolPtr(true),
+			},
+		},
+		{
+			name: "host files",
+			commands: []Command{
+				{Key: "adminConfFilePermissions", Audit: "stat -c %a /etc/kubernetes/admin.conf"},
+				{Key: "kubeletConfFileOwnership", Audit: "stat -c %U:%G $kubelet.confs"},
+				{Key: "kubeletProcess", Audit: "ps -ef | grep kubelet"},
+			},
+			want: &corev1.SecurityContext{
+				AllowPrivilegeEscalation: boolPtr(false),
+				Capabilities:             &corev1.Capabilities{Add: []corev1.Capability{"DAC_READ_SEARCH"}, Drop: []corev1.Capability{"all"}},
+				Privileged:               boolPtr(false),
+				ReadOnlyRootFilesystem:   boolPtr(true),
+			},
+		},
+		{
+			name: "host processes environment and mounts",
+			commands: []Command{
+				{Key: "kubeletEnv", Audit: "cat /proc/$(pidof kubelet)/environ | tr '\\0' '\\n'"},
+				{Key: "etcdTLSConfig", Probe: ProbeEtcd, Audit: "$etcd.confs"},
+			},
+			want: &corev1.SecurityContext{
+				AllowPrivilegeEscalation: boolPtr(false),
+				Capabilities:             &corev1.Capabilities{Add: []corev1.Capability{"DAC_READ_SEARCH", "SYS_PTRACE"}, Drop: []corev1.Capability{"all"}},
+				Privileged:               boolPtr(false),
+				ReadOnlyRootFilesystem:   boolPtr(true),
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.want, containerSecurityContext(requiredCapabilities(tt.commands)))
+		})
+	}
+}
@@ -0,0 +1,168 @@
+package collector
+
+import (
+	"context"
+	"fmt"
+	"regexp"
+	"sort"
+	"strings"
+
+	v1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+const (
+	nodeRoleLabelPrefix = "node-role.kubernetes.io/"
+)
+
+var (
+	// nodeRoles roles supported by commands nodeType and --node-type
+	nodeRoles = []string{MasterNode, WorkerNode, EtcdNode}
+
+	// roleProcesses running processes executable (first argument) per node role
+	roleProcesses = map[string]*regexp.Regexp{
+		MasterNode: regexp.MustCompile(`^(\S*/)?((kube-apiserver|kube-controller-manager|kube-scheduler|openshift-apiserver)(\s|$)|(k3s|rke2) server(\s|$)|hyperkube (kube-)?apiserver(\s|$))`),
+		EtcdNode:   regexp.MustCompile(`^(\S*/)?etcd(\s|$)`),
+		WorkerNode: regexp.MustCompile(`^(\S*/)?((kubelet|kubelite)(\s|$)|(k3s|rke2) (server|agent)(\s|$)|k0s worker(\s|$))`),
+	}
+
+	// roleFiles static pod manifests, k3s/rke2 server markers and kubelet configs per node role
+	roleFiles = map[string][]string{
+		MasterNode: {
+			"/etc/kubernetes/manifests/kube-apiserver.yaml",
+			"/etc/kubernetes/manifests/kube-controller-manager.yaml",
+			"/etc/kubernetes/manifests/kube-scheduler.yaml",
+			"/etc/kubernetes/manifests/kube-apiserver-pod.yaml",
+			"/var/lib/rancher/rke2/agent/pod-manifests/kube-apiserver.yaml",
+			"/var/lib/rancher/rke2/server/token",
+			"/var/lib/rancher/k3s/server/token",
+		},
+		EtcdNode: {
+			"/etc/kubernetes/manifests/etcd.yaml",
+			"/etc/kubernetes/manifests/etcd-pod.yaml",
+			"/var/lib/rancher/rke2/agent/pod-manifests/etcd.yaml",
+			"/var/lib/rancher/rke2/server/db/etcd",
+			"/var/lib/rancher/k3s/server/db/etcd",
+		},
+		WorkerNode: {
+			"/var/lib/kubelet/config.yaml",
+			"/etc/kubernetes/kubelet.conf",
+			"/var/lib/rancher/rke2/agent/kubelet.kubeconfig",
+			"/var/lib/rancher/k3s/agent/kubelet.kubeconfig",
+			"/var/snap/microk8s/current/args/kubelet",
+		},
+	}
+
+	// roleLabels node-role.kubernetes.io/<name> label name per node role
+	roleLabels = map[string]string{
+		"control-plane": MasterNode,
+		"master":        MasterNode,
+		"etcd":          EtcdNode,
+		"worker":        WorkerNode,
+	}
+)
+
+// NodeRoles detect node roles from running control-plane and kubelet processes, static pod manifests, k3s/rke2 server
+// markers, kubelet configs and node-role.kubernetes.io/* labels, override is a comma separated roles list which disable
+// detection. a node registered in the cluster is a worker as it run kubelet, cluster can be nil when not reachable
+func NodeRoles(ctx context.Context, sh Shell, cluster *Cluster, nodeName string, override string) ([]string, error) {
+	if override != "" {
+		return parseNodeRoles(override)
+	}
+	roles := localNodeRoles(sh)
+	if cluster != nil && nodeName != "" {
+		node, err := cluster.clientSet.CoreV1().Nodes().Get(ctx, nodeName, v1.GetOptions{})
+		if err == nil {
+			roles = append(roles, WorkerNode)
+			roles = append(roles, labelNodeRoles(node.Labels)...)
+		}
+	}
+	return normalizeRoles(roles), nil
+}
+
+// parseNodeRoles parse comma separated node roles, example: master,etcd
+func parseNodeRoles(value string) ([]string, error) {
+	roles := make([]string, 0)
+	for _, role := range strings.Split(value, ",") {
+		role = strings.TrimSpace(role)
+		if role == "" {
+			continue
+		}
+		if !contains(nodeRoles, role) {
+			return nil, fmt.Errorf("unknown node type %q, expected one of %s", role, strings.Join(nodeRoles, "|"))
+		}
+		roles = append(roles, role)
+	}
+	return normalizeRoles(roles), nil
+}
+
+// localNodeRoles node roles from running processes, static pod manifests, k3s/rke2 server markers and kubelet configs
+func localNodeRoles(sh Shell) []string {
+	roles := make([]string, 0)
+	// shell output lines are joined with commas, commas in process args are replaced so each entry is a whole process
+	processes, err := sh.Execute("ps -eo args 2>/dev/null | tr ',' ' '")
+	if err == nil {
+		for _, process := range strings.Split(processes, ",") {
+			for role, re := range roleProcesses {
+				if re.MatchString(strings.TrimSpace(process)) {
+					roles = append(roles, role)
+				}
+			}
+		}
+	}
+	for role, files := range roleFiles {
+		output, err := sh.Execute(fmt.Sprintf("ls -d %s 2>/dev/null || true", strings.Join(files, " ")))
+		if err == nil && strings.TrimSpace(output) != "" {
+			roles = append(roles, role)
+		}
+	}
+	return roles
+}
+
+// labelNodeRoles node roles from node-role.kubernetes.io/<name> node labels
+func labelNodeRoles(labels map[string]string) []string {
+	roles := make([]string, 0)
+	for label := range labels {
+		if !strings.HasPrefix(label, nodeRoleLabelPrefix) {
+			continue
+		}
+		if role, ok := roleLabels[strings.TrimPrefix(label, nodeRoleLabelPrefix)]; ok {
+			roles = append(roles, role)
+		}
+	}
+	return roles
+}
+
+// normalizeRoles sorted unique roles, worker when no role is found
+func normalizeRoles(roles []string) []string {
+	if len(roles) == 0 {
+		return []string{WorkerNode}
+	}
+	set := make(map[string]bool)
+	for _, role := range roles {
+		set[role] = true
+	}
+	result := make([]string, 0, len(set))
+	for role := range set {
+		result = append(result, role)
+	}
+	sort.Strings(result)
+	return result
+}
+
+// nodeType primary node type of roles, master when node has control-plane role
+func nodeType(roles []string) string {
+	if contains(roles, MasterNode) {
+		return MasterNode
+	}
+	return WorkerNode
+}
+
+// matchRoles check whether comma separated command node types target any of node roles
+func matchRoles(commandNodeType string, roles []string) bool {
+	for _, nt := range strings.Split(commandNodeType, ",") {
+		if contains(roles, strings.TrimSpace(nt)) {
+			return true
+		}
+	}
+	return false
+}
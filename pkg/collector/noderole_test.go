@@ -0,0 +1,126 @@
+package collector
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// fakeShell shell returning canned output per command prefix
+type fakeShell map[string]string
+
+func (f fakeShell) Execute(commandArgs string) (string, error) {
+	for prefix, output := range f {
+		if strings.HasPrefix(commandArgs, prefix) {
+			return output, nil
+		}
+	}
+	return "", nil
+}
+
+func (f fakeShell) Run(commandArgs string) (string, int, error) {
+	output, err := f.Execute(commandArgs)
+	return output, 0, err
+}
+
+func (f fakeShell) FindNodeType() (string, error) {
+	return nodeType(normalizeRoles(localNodeRoles(f))), nil
+}
+
+func TestLocalNodeRoles(t *testing.T) {
+	tests := []struct {
+		name  string
+		shell fakeShell
+		want  []string
+	}{
+		{
+			name:  "kubeadm control plane with stacked etcd",
+			shell: fakeShell{"ps ": "COMMAND,/sbin/init,kube-apiserver --advertise-address=172.18.0.2 --enable-admission-plugins=NodeRestriction,/usr/bin/kubelet --config=/var/lib/kubelet/config.yaml,etcd --data-dir=/var/lib/etcd"},
+			want:  []string{EtcdNode, MasterNode, WorkerNode},
+		},
+		{
+			name:  "k3s server",
+			shell: fakeShell{"ps ": "COMMAND,/sbin/init,/usr/local/bin/k3s server", "ls -d /etc/kubernetes/manifests/etcd.yaml": "/var/lib/rancher/k3s/server/db/etcd"},
+			want:  []string{EtcdNode, MasterNode, WorkerNode},
+		},
+		{
+			name: "rke2 server static pod manifests",
+			shell: fakeShell{
+				"ls -d /etc/kubernetes/manifests/kube-apiserver.yaml": "/var/lib/rancher/rke2/agent/pod-manifests/kube-apiserver.yaml",
+				"ls -d /var/lib/kubelet/config.yaml":                  "/var/lib/rancher/rke2/agent/kubelet.kubeconfig",
+			},
+			want: []string{MasterNode, WorkerNode},
+		},
+		{
+			name:  "external etcd member without kubelet",
+			shell: fakeShell{"ps ": "COMMAND,/sbin/init,/usr/local/bin/etcd --data-dir=/var/lib/etcd"},
+			want:  []string{EtcdNode},
+		},
+		{
+			name:  "k3s agent",
+			shell: fakeShell{"ps ": "COMMAND,/usr/local/bin/k3s agent,tail -f /var/log/kube-apiserver.log,kubectl logs etcd-0"},
+			want:  []string{WorkerNode},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.want, normalizeRoles(localNodeRoles(tt.shell)))
+		})
+	}
+}
+
+func TestLocalNodeRolesProcessArgsWithCommas(t *testing.T) {
+	// fake ps listing a kubelet and an apiserver which args contain commas followed by role process names
+	bin := t.TempDir()
+	ps := "#!/bin/sh\necho 'COMMAND'\necho '/usr/bin/kubelet --node-labels=a=b,etcd --x'\necho 'kube-apiserver --etcd-servers=https://a:2379,etcd --y'\n"
+	assert.NoError(t, os.WriteFile(filepath.Join(bin, "ps"), []byte(ps), 0o700))
+	t.Setenv("PATH", bin+string(os.PathListSeparator)+os.Getenv("PATH"))
+	assert.Equal(t, []string{MasterNode, WorkerNode}, normalizeRoles(localNodeRoles(onlyProcessesShell{NewShellCmd()})))
+}
+
+// onlyProcessesShell shell executing process listing only, other commands output nothing
+type onlyProcessesShell struct {
+	Shell
+}
+
+func (s onlyProcessesShell) Execute(commandArgs string) (string, error) {
+	if !strings.HasPrefix(commandArgs, "ps ") {
+		return "", nil
+	}
+	return s.Shell.Execute(commandArgs)
+}
+
+func TestLabelNodeRoles(t *testing.T) {
+	labels := map[string]string{
+		"kubernetes.io/hostname":                "kind-control-plane",
+		"node-role.kubernetes.io/control-plane": "",
+		"node-role.kubernetes.io/etcd":          "true",
+		"node-role.kubernetes.io/infra":         "",
+	}
+	assert.Equal(t, []string{EtcdNode, MasterNode}, normalizeRoles(labelNodeRoles(labels)))
+	assert.Equal(t, []string{WorkerNode}, normalizeRoles(labelNodeRoles(nil)))
+}
+
+func TestParseNodeRoles(t *testing.T) {
+	roles, err := parseNodeRoles("master, etcd")
+	assert.NoError(t, err)
+	assert.Equal(t, []string{EtcdNode, MasterNode}, roles)
+	assert.Equal(t, MasterNode, nodeType(roles))
+
+	roles, err = parseNodeRoles("master,worker")
+	assert.NoError(t, err)
+	assert.Equal(t, []string{MasterNode, WorkerNode}, roles)
+
+	// kubelet running on the node do not add the worker role to an etcd only override
+	roles, err = NodeRoles(context.Background(), fakeShell{"ps ": "COMMAND,/usr/bin/kubelet,etcd"}, nil, "", "etcd")
+	assert.NoError(t, err)
+	assert.Equal(t, []string{EtcdNode}, roles)
+	assert.Equal(t, WorkerNode, nodeType(roles))
+
+	_, err = parseNodeRoles("master,infra")
+	assert.Error(t, err)
+}
@@ -32,6 +32,13 @@ func printOutput(nodeData Node, output string, writer io.Writer) error {
 					results = append(results, strconv.Itoa(n))
 				case string:
 					results = append(results, t.(string))
+				default:
+					// probes structured values
+					data, err := json.Marshal(n)
+					if err != nil {
+						return err
+					}
+					results = append(results, string(data))
 				}
 			}
 			if len(results) > 0 {
@@ -49,6 +56,46 @@ func printOutput(nodeData Node, output string, writer io.Writer) error {
 	return nil
 }
 
+// DryRun commands which would be executed on node
+type DryRun struct {
+	NodeType         string    `json:"nodeType"`
+	Roles            []string  `json:"roles,omitempty"`
+	Platform         string    `json:"platform,omitempty"`
+	PlatformEvidence []string  `json:"platformEvidence,omitempty"`
+	Commands         []Command `json:"commands"`
+}
+
+func printDryRun(dryRun DryRun, output string, writer io.Writer) error {
+	switch output {
+	case "json":
+		data, err := json.Marshal(dryRun)
+		if err != nil {
+			return err
+		}
+		fmt.Fprint(writer, string(data))
+	case "table":
+		data := make([][]string, 0, len(dryRun.Commands))
+		for _, c := range dryRun.Commands {
+			data = append(data, []string{c.Key, dryRunCommand(c)})
+		}
+		table := tablewriter.NewWriter(writer)
+		table.SetHeader([]string{"Key", "Command"})
+		table.SetBorder(false)
+		table.SetAutoWrapText(false)
+		table.AppendBulk(data)
+		table.Render()
+	}
+	return nil
+}
+
+// dryRunCommand command audit, prefixed with probe name for probe commands, example: sysctl probe: kernel.panic
+func dryRunCommand(c Command) string {
+	if c.Probe != "" {
+		return fmt.Sprintf("%s probe: %s", c.Probe, c.Audit)
+	}
+	return c.Audit
+}
+
 func join(strs ...string) string {
 	var sb strings.Builder
 	for _, str := range strs {
@@ -58,3 +58,38 @@ func TestPrintOutput(t *testing.T) {
 		})
 	}
 }
+
+func TestPrintDryRun(t *testing.T) {
+	dryRun := DryRun{
+		NodeType: WorkerNode,
+		Platform: native,
+		Commands: []Command{{Key: "kubeletConfFilePermissions", Title: "kubelet.conf file permissions", NodeType: WorkerNode, Audit: "stat -c %a /etc/kubernetes/kubelet.conf"}},
+	}
+	tests := []struct {
+		name   string
+		format string
+		want   string
+	}{
+		{
+			name:   "print json format",
+			format: "json",
+			want:   `{"nodeType":"worker","platform":"k8s","commands":[{"key":"kubeletConfFilePermissions","title":"kubelet.conf file permissions","audit":"stat -c %a /etc/kubernetes/kubelet.conf","nodeType":"worker"}]}`,
+		},
+		{
+			name:   "print table format",
+			format: "table",
+			want: `             KEY             |                 COMMAND                  
+-----------------------------+------------------------------------------
+  kubeletConfFilePermissions | stat -c %a /etc/kubernetes/kubelet.conf  
+`,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			buff := bytes.NewBuffer([]byte{})
+			err := printDryRun(dryRun, tt.format, buff)
+			assert.NoError(t, err)
+			assert.Equal(t, tt.want, buff.String())
+		})
+	}
+}
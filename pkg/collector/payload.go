@@ -0,0 +1,182 @@
+package collector
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"strings"
+
+	"github.com/spf13/cobra"
+	"gopkg.in/yaml.v3"
+)
+
+const (
+	// stdinPayload read payload from stdin
+	stdinPayload = "-"
+	// filePayloadPrefix read payload from file, example: @/etc/node-collector/commands.yaml
+	filePayloadPrefix = "@"
+)
+
+// payload kinds detected on decode
+const (
+	PayloadKindCommands             = "node-commands"
+	PayloadKindNodeConfig           = "node-config"
+	PayloadKindKubeletConfigMapping = "kubelet-config-mapping"
+	PayloadKindKubeletConfig        = "kubelet-config"
+	PayloadKindUnknown              = "unknown"
+)
+
+// payloadLoader resolve payload flags from inline value, file or stdin
+// and verify their signatures
+type payloadLoader struct {
+	verifier  *PayloadVerifier
+	stdin     io.Reader
+	stdinUser string
+}
+
+func newPayloadLoader(cmd *cobra.Command) (*payloadLoader, error) {
+	verifier, err := payloadVerifier(cmd)
+	if err != nil {
+		return nil, err
+	}
+	return &payloadLoader{verifier: verifier, stdin: cmd.InOrStdin()}, nil
+}
+
+// read resolve payload flag value source and return it decoded content
+func (pl *payloadLoader) read(cmd *cobra.Command, name string) ([]byte, error) {
+	value := cmd.Flag(name).Value.String()
+	if value == "" {
+		return nil, nil
+	}
+	raw, err := pl.readSource(name, value)
+	if err != nil {
+		return nil, err
+	}
+	data, err := decodePayload(raw)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read %s: %w", name, err)
+	}
+	return data, nil
+}
+
+// load read payload flag and verify it against its signature flag
+func (pl *payloadLoader) load(cmd *cobra.Command, name string) ([]byte, error) {
+	data, err := pl.read(cmd, name)
+	if err != nil || data == nil {
+		return nil, err
+	}
+	err = pl.verifier.Verify(name, data, cmd.Flag(fmt.Sprintf("%s-signature", name)).Value.String())
+	if err != nil {
+		return nil, err
+	}
+	return data, nil
+}
+
+func (pl *payloadLoader) readSource(name string, value string) ([]byte, error) {
+	switch {
+	case value == stdinPayload:
+		if pl.stdinUser != "" {
+			return nil, fmt.Errorf("%s and %s can not both be read from stdin", pl.stdinUser, name)
+		}
+		pl.stdinUser = name
+		return io.ReadAll(pl.stdin)
+	case strings.HasPrefix(value, filePayloadPrefix):
+		return os.ReadFile(strings.TrimPrefix(value, filePayloadPrefix))
+	default:
+		return []byte(value), nil
+	}
+}
+
+// payloadOrDefault load payload flag, defaultPayload is returned when the flag is not set
+func payloadOrDefault(payloads *payloadLoader, cmd *cobra.Command, name string, defaultPayload func() ([]byte, error)) ([]byte, error) {
+	data, err := payloads.load(cmd, name)
+	if err != nil {
+		return nil, err
+	}
+	if len(data) != 0 {
+		return data, nil
+	}
+	return defaultPayload()
+}
+
+// payloadVerifier build payload signature verifier from public key flags
+func payloadVerifier(cmd *cobra.Command) (*PayloadVerifier, error) {
+	encodedKeys, err := cmd.Flags().GetStringSlice("public-key")
+	if err != nil {
+		return nil, err
+	}
+	keyFiles, err := cmd.Flags().GetStringSlice("public-key-file")
+	if err != nil {
+		return nil, err
+	}
+	required, err := cmd.Flags().GetBool("require-signature")
+	if err != nil {
+		return nil, err
+	}
+	keys, err := LoadPublicKeys(encodedKeys, keyFiles)
+	if err != nil {
+		return nil, err
+	}
+	return NewPayloadVerifier(keys, required), nil
+}
+
+// DetectPayloadKind detect which kind of payload decoded data represent
+func DetectPayloadKind(data []byte) string {
+	content := make(map[string]interface{})
+	if err := yaml.Unmarshal(data, &content); err != nil || len(content) == 0 {
+		return PayloadKindUnknown
+	}
+	if _, ok := content["kubeletconfig"]; ok {
+		return PayloadKindKubeletConfig
+	}
+	if _, ok := content["commands"]; ok {
+		return PayloadKindCommands
+	}
+	if _, ok := content["node"]; ok {
+		return PayloadKindNodeConfig
+	}
+	for _, v := range content {
+		path, ok := v.(string)
+		if !ok || !strings.HasPrefix(path, "kubeletconfig.") {
+			return PayloadKindUnknown
+		}
+	}
+	return PayloadKindKubeletConfigMapping
+}
+
+// EncodeFile compress and base64 encode a file (or stdin) to be used as payload flag value
+func EncodeFile(cmd *cobra.Command, path string) error {
+	var data []byte
+	var err error
+	if path == stdinPayload {
+		data, err = io.ReadAll(cmd.InOrStdin())
+	} else {
+		data, err = os.ReadFile(path)
+	}
+	if err != nil {
+		return err
+	}
+	compression := cmd.Flag("compression").Value.String()
+	encoded, err := EncodeWithCompression(data, compression)
+	if err != nil {
+		return err
+	}
+	fmt.Fprintln(cmd.OutOrStdout(), encoded)
+	return nil
+}
+
+// DecodePayload decode a payload flag value, print it content and the detected payload kind
+func DecodePayload(cmd *cobra.Command, payload string) error {
+	pl := &payloadLoader{stdin: cmd.InOrStdin()}
+	raw, err := pl.readSource("payload", payload)
+	if err != nil {
+		return err
+	}
+	data, err := decodePayload(raw)
+	if err != nil {
+		return err
+	}
+	fmt.Fprintf(cmd.ErrOrStderr(), "payload kind: %s\n", DetectPayloadKind(data))
+	fmt.Fprint(cmd.OutOrStdout(), string(data))
+	return nil
+}
@@ -0,0 +1,187 @@
+package collector
+
+import (
+	"bytes"
+	"compress/gzip"
+	"encoding/base64"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func gzipCompress(data []byte) ([]byte, error) {
+	var buf bytes.Buffer
+	w := gzip.NewWriter(&buf)
+	if _, err := w.Write(data); err != nil {
+		return nil, err
+	}
+	if err := w.Close(); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+func TestDecodePayload(t *testing.T) {
+	content, err := os.ReadFile("./testdata/fixture/single-check.yaml")
+	assert.NoError(t, err)
+	bz, err := bzip2Compress(content)
+	assert.NoError(t, err)
+	gz, err := gzipCompress(content)
+	assert.NoError(t, err)
+
+	tests := []struct {
+		name    string
+		payload []byte
+	}{
+		{
+			name:    "base64 bzip2",
+			payload: []byte(base64.StdEncoding.EncodeToString(bz)),
+		},
+		{
+			name:    "base64 gzip",
+			payload: []byte(base64.StdEncoding.EncodeToString(gz)),
+		},
+		{
+			name:    "base64 uncompressed",
+			payload: []byte(base64.StdEncoding.EncodeToString(content)),
+		},
+		{
+			name:    "base64 wrapped lines",
+			payload: []byte(wrap(base64.StdEncoding.EncodeToString(bz), 76)),
+		},
+		{
+			name:    "raw bzip2",
+			payload: bz,
+		},
+		{
+			name:    "raw gzip",
+			payload: gz,
+		},
+		{
+			name:    "plain yaml",
+			payload: content,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := decodePayload(tt.payload)
+			assert.NoError(t, err)
+			assert.Equal(t, string(content), string(got))
+		})
+	}
+}
+
+func TestDecodePayloadInvalidBase64(t *testing.T) {
+	encoded := base64.StdEncoding.EncodeToString([]byte("commands:\n  - key: kubeletConfFilePermissions\n"))
+	tests := []struct {
+		name    string
+		payload string
+		wantErr string
+	}{
+		{
+			name:    "truncated",
+			payload: encoded[:len(encoded)-3],
+			wantErr: "invalid base64 payload: illegal base64 data at input byte 60",
+		},
+		{
+			name:    "binary content",
+			payload: base64.StdEncoding.EncodeToString([]byte{0xff, 0xfe, 0x00, 0x01}),
+			wantErr: "invalid base64 payload: decoded content is neither compressed nor text",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := decodePayload([]byte(tt.payload))
+			assert.EqualError(t, err, tt.wantErr)
+		})
+	}
+
+	// wrapped base64 lines are decoded
+	got, err := decodePayload([]byte(encoded[:20] + "\n" + encoded[20:] + "\n"))
+	assert.NoError(t, err)
+	assert.Equal(t, "commands:\n  - key: kubeletConfFilePermissions\n", string(got))
+}
+
+func TestPayloadLoaderReadSource(t *testing.T) {
+	file := filepath.Join(t.TempDir(), "commands.yaml")
+	err := os.WriteFile(file, []byte("from file"), 0600)
+	assert.NoError(t, err)
+	pl := &payloadLoader{stdin: strings.NewReader("from stdin")}
+
+	got, err := pl.readSource("node-commands", "inline")
+	assert.NoError(t, err)
+	assert.Equal(t, "inline", string(got))
+
+	got, err = pl.readSource("node-commands", "@"+file)
+	assert.NoError(t, err)
+	assert.Equal(t, "from file", string(got))
+
+	got, err = pl.readSource("node-commands", "-")
+	assert.NoError(t, err)
+	assert.Equal(t, "from stdin", string(got))
+
+	_, err = pl.readSource("node-config", "-")
+	assert.Error(t, err)
+}
+
+func wrap(s string, width int) string {
+	var sb strings.Builder
+	for len(s) > width {
+		sb.WriteString(s[:width] + "\n")
+		s = s[width:]
+	}
+	sb.WriteString(s)
+	return sb.String()
+}
+
+func TestDetectPayloadKind(t *testing.T) {
+	tests := []struct {
+		name string
+		file string
+		want string
+	}{
+		{
+			name: "node commands",
+			file: "./testdata/fixture/single-check.yaml",
+			want: PayloadKindCommands,
+		},
+		{
+			name: "node config",
+			file: "./config/config.yaml",
+			want: PayloadKindNodeConfig,
+		},
+		{
+			name: "kubelet config mapping",
+			file: "./testdata/fixture/kubeletconfig-mapping.yaml",
+			want: PayloadKindKubeletConfigMapping,
+		},
+		{
+			name: "kubelet config",
+			file: "./testdata/fixture/node_config.json",
+			want: PayloadKindKubeletConfig,
+		},
+		{
+			name: "spec version mapping",
+			file: "./testdata/fixture/mapping.yaml",
+			want: PayloadKindUnknown,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			content, err := os.ReadFile(tt.file)
+			assert.NoError(t, err)
+			for _, compression := range []string{CompressionBzip2, CompressionGzip, CompressionNone} {
+				encoded, err := EncodeWithCompression(content, compression)
+				assert.NoError(t, err)
+				decoded, err := decodePayload([]byte(encoded))
+				assert.NoError(t, err)
+				assert.Equal(t, tt.want, DetectPayloadKind(decoded))
+			}
+		})
+	}
+}
@@ -0,0 +1,113 @@
+package collector
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+const (
+	// ProbeSysctl read kernel parameters from /proc/sys
+	ProbeSysctl = "sysctl"
+)
+
+// ProbeContext node context available to probes
+type ProbeContext struct {
+	// Root node root file system path
+	Root string
+	// Now probe time, default to current time
+	Now time.Time
+	// Params node config variables resolved for the node, example: $apiserver.confs
+	Params map[string]string
+}
+
+func (ctx ProbeContext) now() time.Time {
+	if ctx.Now.IsZero() {
+		return time.Now()
+	}
+	return ctx.Now
+}
+
+// Probe native check executed instead of shell audit command, arg is the command audit with variables resolved
+type Probe func(ctx ProbeContext, arg string) ([]interface{}, error)
+
+// probes registry by command probe name
+var probes = map[string]Probe{
+	ProbeSysctl:      sysctlProbe,
+	ProbeCertificate: certificateProbe,
+	ProbeKubeconfig:  kubeconfigProbe,
+	ProbeStaticPod:   staticPodProbe,
+	ProbeFlag:        flagProbe,
+	ProbeSystemd:     systemdProbe,
+	ProbeEtcd:        etcdProbe,
+	ProbeAuditPolicy: auditPolicyProbe,
+	ProbeEncryption:  encryptionProbe,
+	ProbeAdmission:   admissionProbe,
+	ProbeAuthConfig:  authConfigProbe,
+}
+
+// probeNames registered probe names sorted
+func probeNames() []string {
+	names := make([]string, 0, len(probes))
+	for name := range probes {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// runProbe run command probe by name
+func runProbe(ctx ProbeContext, c Command) ([]interface{}, error) {
+	probe, ok := probes[c.Probe]
+	if !ok {
+		return nil, fmt.Errorf("command %s: unknown probe %q, expected one of %s", c.Key, c.Probe, strings.Join(probeNames(), "|"))
+	}
+	return probe(ctx, c.Audit)
+}
+
+// sysctlProbe read comma separated kernel parameters from /proc/sys, example: vm.overcommit_memory,kernel.panic.
+// parameters may set an expected value, example: kernel.panic=10, in which case only mismatching parameters
+// are reported with their actual value, example: kernel.panic=0, missing parameters are reported with empty value
+func sysctlProbe(ctx ProbeContext, arg string) ([]interface{}, error) {
+	values := make([]string, 0)
+	for _, param := range strings.Split(arg, ",") {
+		name, expected, withExpectation := strings.Cut(strings.TrimSpace(param), "=")
+		if name == "" {
+			continue
+		}
+		actual, found := readSysctl(ctx.Root, name)
+		switch {
+		case withExpectation && (!found || actual != strings.TrimSpace(expected)):
+			values = append(values, fmt.Sprintf("%s=%s", name, actual))
+		case !withExpectation && found:
+			values = append(values, actual)
+		}
+	}
+	return probeValues(values), nil
+}
+
+// readSysctl kernel parameter value, whitespace separated values are normalized to a single space
+func readSysctl(root string, name string) (string, bool) {
+	data, err := os.ReadFile(filepath.Join(root, "/proc/sys", strings.ReplaceAll(name, ".", "/")))
+	if err != nil {
+		return "", false
+	}
+	return strings.Join(strings.Fields(string(data)), " "), true
+}
+
+// probeValues convert probe values to output values, numeric values are converted to int as shell output values
+func probeValues(values []string) []interface{} {
+	result := make([]interface{}, 0, len(values))
+	for _, v := range values {
+		if intVal, err := strconv.Atoi(v); err == nil {
+			result = append(result, intVal)
+			continue
+		}
+		result = append(result, v)
+	}
+	return result
+}
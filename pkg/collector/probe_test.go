@@ -0,0 +1,89 @@
+package collector
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestSysctlProbe(t *testing.T) {
+	root := t.TempDir()
+	sysctls := map[string]string{
+		"vm/overcommit_memory":     "1\n",
+		"vm/panic_on_oom":          "0\n",
+		"kernel/panic":             "0\n",
+		"kernel/panic_on_oops":     "1\n",
+		"kernel/printk":            "4\t4\t1\t7\n",
+		"kernel/keys/root_maxkeys": "1000000\n",
+	}
+	for name, value := range sysctls {
+		path := filepath.Join(root, "/proc/sys", name)
+		assert.NoError(t, os.MkdirAll(filepath.Dir(path), 0o755))
+		assert.NoError(t, os.WriteFile(path, []byte(value), 0o600))
+	}
+	tests := []struct {
+		name string
+		arg  string
+		want []interface{}
+	}{
+		{
+			name: "single value",
+			arg:  "vm.overcommit_memory",
+			want: []interface{}{1},
+		},
+		{
+			name: "multiple values",
+			arg:  "kernel.panic, kernel.printk, kernel.unknown",
+			want: []interface{}{0, "4 4 1 7"},
+		},
+		{
+			name: "expectations mismatch",
+			arg:  "vm.overcommit_memory=1,vm.panic_on_oom=0,kernel.panic=10,kernel.panic_on_oops=1,kernel.keys.root_maxkeys=1000000,kernel.keys.root_maxbytes=25000000",
+			want: []interface{}{"kernel.panic=0", "kernel.keys.root_maxbytes="},
+		},
+		{
+			name: "expectations match",
+			arg:  "vm.overcommit_memory=1,kernel.printk=4 4 1 7",
+			want: []interface{}{},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := sysctlProbe(ProbeContext{Root: root}, tt.arg)
+			assert.NoError(t, err)
+			assert.Equal(t, tt.want, got)
+		})
+	}
+}
+
+func TestExecuteCommandsProbe(t *testing.T) {
+	root := t.TempDir()
+	path := filepath.Join(root, "/proc/sys/kernel/panic")
+	assert.NoError(t, os.MkdirAll(filepath.Dir(path), 0o755))
+	assert.NoError(t, os.WriteFile(path, []byte("10\n"), 0o600))
+
+	commands := []Command{
+		{Key: "kernelPanic", Probe: ProbeSysctl, Audit: "kernel.panic"},
+		{Key: "kubeletConfFilePermissions", Audit: "stat -c %a /etc/kubernetes/kubelet.conf"},
+	}
+	sh := fakeShell{"stat -c %a /etc/kubernetes/kubelet.conf": "600"}
+	got, err := ExecuteCommands(sh, ProbeContext{Root: root}, commands)
+	assert.NoError(t, err)
+	assert.Equal(t, map[string]*Info{
+		"kernelPanic":                {Values: []interface{}{10}},
+		"kubeletConfFilePermissions": {Values: []interface{}{600}},
+	}, got)
+
+	got, err = ExecuteCommands(sh, ProbeContext{Root: root}, []Command{
+		{Key: "unknown", Probe: "unknown", Audit: "x"},
+		{Key: "kubeletConfFilePermissions", Audit: "stat -c %a /etc/kubernetes/kubelet.conf"},
+	})
+	assert.NoError(t, err)
+	assert.Equal(t, map[string]*Info{
+		"unknown":                    {Values: []interface{}{}, Error: `command unknown: unknown probe "unknown", expected one of ` + strings.Join(probeNames(), "|")},
+		"kubeletConfFilePermissions": {Values: []interface{}{600}},
+	}, got)
+}
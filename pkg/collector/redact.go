@@ -0,0 +1,146 @@
+package collector
+
+import (
+	"encoding/json"
+	"fmt"
+	"regexp"
+	"sort"
+	"strings"
+)
+
+const (
+	redactedValue = "[REDACTED]"
+
+	redactionPrivateKey = "private-key"
+	redactionCustom     = "custom"
+)
+
+var (
+	privateKeyBeginRe = regexp.MustCompile(`-----BEGIN [A-Z ]*PRIVATE KEY-----`)
+	privateKeyEndRe   = regexp.MustCompile(`-----END [A-Z ]*PRIVATE KEY-----`)
+)
+
+// redactionRule replace matches of pattern, when pattern has a sub expression only its first one is replaced
+type redactionRule struct {
+	name    string
+	pattern *regexp.Regexp
+}
+
+// defaultRedactionRules built-in rules for tokens, passwords, private keys and bearer headers
+var defaultRedactionRules = []redactionRule{
+	{name: redactionPrivateKey, pattern: regexp.MustCompile(`-----BEGIN [A-Z ]*PRIVATE KEY-----[\s\S]*?-----END [A-Z ]*PRIVATE KEY-----`)},
+	{name: "bearer", pattern: regexp.MustCompile(`(?i)\bbearer\s+([A-Za-z0-9\-._~+/]+=*)`)},
+	{name: "flag", pattern: regexp.MustCompile(`(?i)--[\w-]*(?:token|password|secret)(?:=|\s+)["']?([^\s"',]+)`)},
+	{name: "key-value", pattern: regexp.MustCompile(`(?i)\b[\w.-]*(?:token|password|passwd|secret|api[_-]?key)["']?\s*[:=]\s*["']?([^\s"',}]+)`)},
+}
+
+// redactor replace secrets in collected values before they are printed
+type redactor struct {
+	rules []redactionRule
+}
+
+// newRedactor built-in rules followed by user regex rules
+func newRedactor(patterns []string) (*redactor, error) {
+	rules := append([]redactionRule{}, defaultRedactionRules...)
+	for _, p := range patterns {
+		re, err := regexp.Compile(p)
+		if err != nil {
+			return nil, fmt.Errorf("invalid redaction rule %q: %w", p, err)
+		}
+		rules = append(rules, redactionRule{name: redactionCustom, pattern: re})
+	}
+	return &redactor{rules: rules}, nil
+}
+
+// redactInfo redact info values in place and record the names of the matching rules
+func (r *redactor) redactInfo(info map[string]*Info) {
+	for _, i := range info {
+		values, ok := i.Values.([]interface{})
+		if !ok {
+			continue
+		}
+		matched := make(map[string]bool)
+		values = redactPrivateKeyLines(values, matched)
+		for idx, v := range values {
+			values[idx] = r.redactValue(v, matched)
+		}
+		i.Values = values
+		for name := range matched {
+			i.Redacted = append(i.Redacted, name)
+		}
+		sort.Strings(i.Redacted)
+	}
+}
+
+// redactPrivateKeyLines replace the values of a private key split line by line by the shell output delimiter,
+// from the BEGIN line to the END line, by a single redacted value. a key without END line is redacted to the last value
+func redactPrivateKeyLines(values []interface{}, matched map[string]bool) []interface{} {
+	redacted := make([]interface{}, 0, len(values))
+	inKey := false
+	for _, v := range values {
+		s, ok := v.(string)
+		if inKey {
+			if ok && privateKeyEndRe.MatchString(s) {
+				inKey = false
+			}
+			continue
+		}
+		if ok && privateKeyBeginRe.MatchString(s) && !privateKeyEndRe.MatchString(s) {
+			matched[redactionPrivateKey] = true
+			redacted = append(redacted, redactedValue)
+			inKey = true
+			continue
+		}
+		redacted = append(redacted, v)
+	}
+	return redacted
+}
+
+// redactValue redact string values, structured probe values are redacted through their json representation
+func (r *redactor) redactValue(value interface{}, matched map[string]bool) interface{} {
+	switch v := value.(type) {
+	case int, bool, nil:
+		return value
+	case string:
+		return r.redact(v, matched)
+	}
+	data, err := json.Marshal(value)
+	if err != nil {
+		return value
+	}
+	redacted := r.redact(string(data), matched)
+	if redacted == string(data) {
+		return value
+	}
+	var result interface{}
+	if err := json.Unmarshal([]byte(redacted), &result); err != nil {
+		return redacted
+	}
+	return result
+}
+
+func (r *redactor) redact(s string, matched map[string]bool) string {
+	for _, rule := range r.rules {
+		var b strings.Builder
+		last, replaced := 0, false
+		for _, m := range rule.pattern.FindAllStringSubmatchIndex(s, -1) {
+			start, end := m[0], m[1]
+			if len(m) >= 4 && m[2] >= 0 {
+				start, end = m[2], m[3]
+			}
+			if secret := s[start:end]; secret == redactedValue || secret == "true" || secret == "false" || secret == "null" {
+				continue
+			}
+			matched[rule.name] = true
+			replaced = true
+			b.WriteString(s[last:start])
+			b.WriteString(redactedValue)
+			last = end
+		}
+		if replaced {
+			b.WriteString(s[last:])
+			s = b.String()
+		}
+	}
+	return s
+}
@@ -0,0 +1,290 @@
+package collector
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"log/slog"
+	"net/http"
+	"os"
+	"os/signal"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"syscall"
+	"time"
+
+	"github.com/spf13/cobra"
+)
+
+const (
+	serveShutdownTimeout = 10 * time.Second
+	metricsPrefix        = "node_collector_"
+)
+
+// Serve collect node data on an interval and serve the latest node document, health and metrics over HTTP
+func Serve(cmd *cobra.Command) error {
+	interval, err := cmd.Flags().GetDuration("interval")
+	if err != nil {
+		return err
+	}
+	if interval <= 0 {
+		return fmt.Errorf("interval must be positive, got %s", interval)
+	}
+	ctx, stop := signal.NotifyContext(cmd.Context(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+	nc, err := newNodeCollector(ctx, cmd, false)
+	if err != nil {
+		return err
+	}
+	ns := newNodeServer()
+	server := &http.Server{
+		Addr:              cmd.Flag("listen").Value.String(),
+		Handler:           ns.handler(),
+		ReadHeaderTimeout: 10 * time.Second,
+	}
+	go ns.run(ctx, nc, interval)
+	go func() {
+		<-ctx.Done()
+		shutdownCtx, cancel := context.WithTimeout(context.Background(), serveShutdownTimeout)
+		defer cancel()
+		if err := server.Shutdown(shutdownCtx); err != nil {
+			slog.Error("failed to shutdown server", "error", err)
+		}
+	}()
+	slog.Info("serving node info", "listen", server.Addr, "interval", interval)
+	if err := server.ListenAndServe(); err != nil && !errors.Is(err, http.ErrServerClosed) {
+		return err
+	}
+	return nil
+}
+
+// nodeServer latest collected node document and collection metrics
+type nodeServer struct {
+	mu       sync.RWMutex
+	document []byte
+	etag     string
+	updated  time.Time
+	metrics  *collectorMetrics
+}
+
+func newNodeServer() *nodeServer {
+	return &nodeServer{metrics: newCollectorMetrics()}
+}
+
+// run collect node data immediately then on every interval until ctx is done
+func (ns *nodeServer) run(ctx context.Context, nc *nodeCollector, interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		ns.collectOnce(ctx, nc, interval)
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+func (ns *nodeServer) collectOnce(ctx context.Context, nc *nodeCollector, timeout time.Duration) {
+	collectCtx, cancel := context.WithTimeout(ctx, timeout)
+	defer cancel()
+	if err := nc.resolve(collectCtx); err != nil {
+		ns.metrics.observeCollection(false)
+		slog.Error("failed to resolve node commands", "error", err)
+		return
+	}
+	node, err := nc.collect(collectCtx, ns.metrics.observeCommand)
+	if err != nil {
+		ns.metrics.observeCollection(false)
+		slog.Error("failed to collect node info", "error", err)
+		return
+	}
+	if err := ns.update(node); err != nil {
+		ns.metrics.observeCollection(false)
+		slog.Error("failed to encode node info", "error", err)
+		return
+	}
+	ns.metrics.observeCollection(true)
+}
+
+// update store node document and its ETag, the ETag is computed without metadata so the document served and
+// its ETag are kept while collected data do not change
+func (ns *nodeServer) update(node Node) error {
+	data, err := json.Marshal(node)
+	if err != nil {
+		return err
+	}
+	content := node
+	content.Metadata = nil
+	contentData, err := json.Marshal(content)
+	if err != nil {
+		return err
+	}
+	sum := sha256.Sum256(contentData)
+	etag := fmt.Sprintf("%q", hex.EncodeToString(sum[:16]))
+	ns.mu.Lock()
+	defer ns.mu.Unlock()
+	if etag == ns.etag {
+		return nil
+	}
+	ns.document = data
+	ns.etag = etag
+	ns.updated = time.Now()
+	return nil
+}
+
+func (ns *nodeServer) handler() http.Handler {
+	mux := http.NewServeMux()
+	mux.HandleFunc("/nodeinfo", ns.serveNodeInfo)
+	mux.HandleFunc("/healthz", func(w http.ResponseWriter, _ *http.Request) {
+		fmt.Fprint(w, "ok")
+	})
+	mux.HandleFunc("/readyz", func(w http.ResponseWriter, _ *http.Request) {
+		ns.mu.RLock()
+		ready := ns.document != nil
+		ns.mu.RUnlock()
+		if !ready {
+			http.Error(w, "node info not collected yet", http.StatusServiceUnavailable)
+			return
+		}
+		fmt.Fprint(w, "ok")
+	})
+	mux.HandleFunc("/metrics", func(w http.ResponseWriter, _ *http.Request) {
+		w.Header().Set("Content-Type", "text/plain; version=0.0.4")
+		ns.metrics.write(w)
+	})
+	return mux
+}
+
+func (ns *nodeServer) serveNodeInfo(w http.ResponseWriter, r *http.Request) {
+	ns.mu.RLock()
+	document, etag, updated := ns.document, ns.etag, ns.updated
+	ns.mu.RUnlock()
+	if document == nil {
+		http.Error(w, "node info not collected yet", http.StatusServiceUnavailable)
+		return
+	}
+	w.Header().Set("ETag", etag)
+	w.Header().Set("Last-Modified", updated.UTC().Format(http.TimeFormat))
+	if etagMatch(r.Header.Values("If-None-Match"), etag) {
+		w.WriteHeader(http.StatusNotModified)
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	_, _ = w.Write(document)
+}
+
+// etagMatch whether If-None-Match header values match etag, values are comma separated lists of entity tags
+// compared with the weak comparison, example: W/"abc", "def" or *
+func etagMatch(headers []string, etag string) bool {
+	for _, header := range headers {
+		for _, tag := range strings.Split(header, ",") {
+			tag = strings.TrimSpace(tag)
+			if tag == "*" || strings.TrimPrefix(tag, "W/") == strings.TrimPrefix(etag, "W/") {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// collectorMetrics per command and collection metrics exposed in prometheus text format
+type collectorMetrics struct {
+	mu                  sync.Mutex
+	durations           map[string]float64
+	failures            map[string]int
+	lastSuccess         map[string]time.Time
+	collections         int
+	collectionFailures  int
+	lastCollection      time.Time
+	lastCollectionValid bool
+}
+
+func newCollectorMetrics() *collectorMetrics {
+	return &collectorMetrics{
+		durations:   make(map[string]float64),
+		failures:    make(map[string]int),
+		lastSuccess: make(map[string]time.Time),
+	}
+}
+
+func (m *collectorMetrics) observeCommand(key string, duration time.Duration, failed bool) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.durations[key] = duration.Seconds()
+	if _, ok := m.failures[key]; !ok {
+		m.failures[key] = 0
+	}
+	if failed {
+		m.failures[key]++
+		return
+	}
+	m.lastSuccess[key] = time.Now()
+}
+
+func (m *collectorMetrics) observeCollection(success bool) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.collections++
+	if !success {
+		m.collectionFailures++
+		return
+	}
+	m.lastCollection = time.Now()
+	m.lastCollectionValid = true
+}
+
+func (m *collectorMetrics) write(w io.Writer) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	keys := make([]string, 0, len(m.durations))
+	for key := range m.durations {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	writeMetric(w, "command_duration_seconds", "gauge", "Duration of the last command execution in seconds.", keys, func(key string) (float64, bool) {
+		return m.durations[key], true
+	})
+	writeMetric(w, "command_failures_total", "counter", "Number of failed command executions.", keys, func(key string) (float64, bool) {
+		return float64(m.failures[key]), true
+	})
+	writeMetric(w, "command_last_success_timestamp_seconds", "gauge", "Unix time of the last successful command execution.", keys, func(key string) (float64, bool) {
+		t, ok := m.lastSuccess[key]
+		return float64(t.UnixNano()) / 1e9, ok
+	})
+	writeMetric(w, "collections_total", "counter", "Number of node info collections.", nil, func(string) (float64, bool) {
+		return float64(m.collections), true
+	})
+	writeMetric(w, "collection_failures_total", "counter", "Number of failed node info collections.", nil, func(string) (float64, bool) {
+		return float64(m.collectionFailures), true
+	})
+	writeMetric(w, "last_collection_timestamp_seconds", "gauge", "Unix time of the last successful node info collection.", nil, func(string) (float64, bool) {
+		return float64(m.lastCollection.UnixNano()) / 1e9, m.lastCollectionValid
+	})
+}
+
+// writeMetric write metric samples, one per key labelled by key or a single unlabelled sample when keys is nil
+func writeMetric(w io.Writer, name string, kind string, help string, keys []string, value func(key string) (float64, bool)) {
+	fmt.Fprintf(w, "# HELP %s%s %s\n# TYPE %s%s %s\n", metricsPrefix, name, help, metricsPrefix, name, kind)
+	if keys == nil {
+		if v, ok := value(""); ok {
+			fmt.Fprintf(w, "%s%s %s\n", metricsPrefix, name, strconv.FormatFloat(v, 'f', -1, 64))
+		}
+		return
+	}
+	for _, key := range keys {
+		if v, ok := value(key); ok {
+			fmt.Fprintf(w, "%s%s{key=\"%s\"} %s\n", metricsPrefix, name, escapeLabel(key), strconv.FormatFloat(v, 'f', -1, 64))
+		}
+	}
+}
+
+func escapeLabel(value string) string {
+	return strings.NewReplacer(`\`, `\\`, `"`, `\"`, "\n", `\n`).Replace(value)
+}
@@ -0,0 +1,123 @@
+package collector
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"regexp"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestNodeServer(t *testing.T) {
+	ns := newNodeServer()
+	handler := ns.handler()
+	get := func(path string, header map[string]string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(http.MethodGet, path, nil)
+		for k, v := range header {
+			req.Header.Set(k, v)
+		}
+		rec := httptest.NewRecorder()
+		handler.ServeHTTP(rec, req)
+		return rec
+	}
+
+	assert.Equal(t, http.StatusOK, get("/healthz", nil).Code)
+	assert.Equal(t, http.StatusServiceUnavailable, get("/readyz", nil).Code)
+	assert.Equal(t, http.StatusServiceUnavailable, get("/nodeinfo", nil).Code)
+
+	node := Node{APIVersion: Version, Kind: Kind, Type: WorkerNode, Metadata: map[string]string{"creationTimestamp": "2024-06-01T00:00:00Z"},
+		Info: map[string]*Info{"kubeletConfFilePermissions": {Values: []interface{}{600}}}}
+	assert.NoError(t, ns.update(node))
+	assert.Equal(t, http.StatusOK, get("/readyz", nil).Code)
+	rec := get("/nodeinfo", nil)
+	assert.Equal(t, http.StatusOK, rec.Code)
+	assert.Equal(t, "application/json", rec.Header().Get("Content-Type"))
+	assert.JSONEq(t, `{"apiVersion":"v1","kind":"NodeInfo","metadata":{"creationTimestamp":"2024-06-01T00:00:00Z"},"type":"worker","info":{"kubeletConfFilePermissions":{"values":[600]}}}`, rec.Body.String())
+	etag := rec.Header().Get("ETag")
+	assert.NotEmpty(t, etag)
+	assert.Equal(t, http.StatusNotModified, get("/nodeinfo", map[string]string{"If-None-Match": etag}).Code)
+
+	// unchanged data keep the document and its ETag
+	node.Metadata = map[string]string{"creationTimestamp": "2024-06-01T00:05:00Z"}
+	assert.NoError(t, ns.update(node))
+	rec = get("/nodeinfo", nil)
+	assert.Equal(t, etag, rec.Header().Get("ETag"))
+	assert.Contains(t, rec.Body.String(), "2024-06-01T00:00:00Z")
+
+	node.Info["kubeletConfFilePermissions"] = &Info{Values: []interface{}{644}}
+	assert.NoError(t, ns.update(node))
+	rec = get("/nodeinfo", map[string]string{"If-None-Match": etag})
+	assert.Equal(t, http.StatusOK, rec.Code)
+	assert.NotEqual(t, etag, rec.Header().Get("ETag"))
+}
+
+func TestCollectorMetrics(t *testing.T) {
+	m := newCollectorMetrics()
+	m.observeCommand("kubeletConfFilePermissions", 1500*time.Millisecond, false)
+	m.observeCommand("etcdDataDirOwnership", 250*time.Millisecond, true)
+	m.observeCommand("etcdDataDirOwnership", 250*time.Millisecond, true)
+	m.observeCollection(false)
+	buff := new(strings.Builder)
+	m.write(buff)
+	got := regexp.MustCompile(`(?m)(timestamp_seconds\{.*\}) [0-9.]+$`).ReplaceAllString(buff.String(), "$1 <timestamp>")
+	assert.Equal(t, `# HELP node_collector_command_duration_seconds Duration of the last command execution in seconds.
+# TYPE node_collector_command_duration_seconds gauge
+node_collector_command_duration_seconds{key="etcdDataDirOwnership"} 0.25
+node_collector_command_duration_seconds{key="kubeletConfFilePermissions"} 1.5
+# HELP node_collector_command_failures_total Number of failed command executions.
+# TYPE node_collector_command_failures_total counter
+node_collector_command_failures_total{key="etcdDataDirOwnership"} 2
+node_collector_command_failures_total{key="kubeletConfFilePermissions"} 0
+# HELP node_collector_command_last_success_timestamp_seconds Unix time of the last successful command execution.
+# TYPE node_collector_command_last_success_timestamp_seconds gauge
+node_collector_command_last_success_timestamp_seconds{key="kubeletConfFilePermissions"} <timestamp>
+# HELP node_collector_collections_total Number of node info collections.
+# TYPE node_collector_collections_total counter
+node_collector_collections_total 1
+# HELP node_collector_collection_failures_total Number of failed node info collections.
+# TYPE node_collector_collection_failures_total counter
+node_collector_collection_failures_total 1
+# HELP node_collector_last_collection_timestamp_seconds Unix time of the last successful node info collection.
+# TYPE node_collector_last_collection_timestamp_seconds gauge
+`, got)
+}
+
+func TestExecuteCommandsObserver(t *testing.T) {
+	observed := make(map[string]bool)
+	commands := []Command{
+		{Key: "succeed", Audit: "echo 600"},
+		{Key: "fail", Audit: "exit 3"},
+		{Key: "probe", Probe: ProbeSysctl, Audit: "kernel.panic"},
+	}
+	got, err := executeCommands(NewShellCmd(), ProbeContext{Root: t.TempDir()}, commands, func(key string, duration time.Duration, failed bool) {
+		observed[key] = failed
+	})
+	assert.NoError(t, err)
+	assert.Equal(t, map[string]bool{"succeed": false, "fail": true, "probe": false}, observed)
+	assert.Equal(t, []interface{}{600}, got["succeed"].Values)
+	assert.Equal(t, []interface{}{}, got["probe"].Values)
+}
+
+func TestEtagMatch(t *testing.T) {
+	tests := []struct {
+		name    string
+		headers []string
+		want    bool
+	}{
+		{name: "no header", want: false},
+		{name: "exact", headers: []string{`"abc"`}, want: true},
+		{name: "weak", headers: []string{`W/"abc"`}, want: true},
+		{name: "list", headers: []string{`"def", W/"abc"`}, want: true},
+		{name: "several headers", headers: []string{`"def"`, `"abc"`}, want: true},
+		{name: "wildcard", headers: []string{"*"}, want: true},
+		{name: "mismatch", headers: []string{`"def", W/"ghi"`}, want: false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.want, etagMatch(tt.headers, `"abc"`))
+		})
+	}
+}
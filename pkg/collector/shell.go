@@ -1,6 +1,7 @@
 package collector
 
 import (
+	"errors"
 	"os/exec"
 	"strings"
 )
@@ -9,7 +10,9 @@ const (
 	// WorkerNode worker node type
 	WorkerNode = "worker"
 	// MasterNode master Node type
-	MasterNode   = "master"
+	MasterNode = "master"
+	// EtcdNode etcd Node type
+	EtcdNode     = "etcd"
 	shellCommand = "sh"
 )
 
@@ -23,6 +26,8 @@ var (
 // Shell command interface to preform shell exec commands
 type Shell interface {
 	Execute(commandArgs string) (string, error)
+	// Run execute a shell command and return its output and exit code, error only when the command can not be started
+	Run(commandArgs string) (string, int, error)
 	FindNodeType() (string, error)
 }
 
@@ -36,37 +41,30 @@ type cmd struct {
 
 // Execute execute a shell command and retun it output or error
 func (e *cmd) Execute(commandArgs string) (string, error) {
+	output, _, err := e.Run(commandArgs)
+	if err != nil {
+		return "", nil
+	}
+	return output, nil
+}
+
+// Run execute a shell command and return it output and exit code, output is empty on non zero exit code as with Execute
+func (e *cmd) Run(commandArgs string) (string, int, error) {
 	cm := exec.Command(shellCommand, "-c", commandArgs)
 	output, err := cm.CombinedOutput()
 	if err != nil {
-		return "", nil
+		var exitErr *exec.ExitError
+		if errors.As(err, &exitErr) {
+			return "", exitErr.ExitCode(), nil
+		}
+		return "", -1, err
 	}
 	// trim newline
 	outPutWithDelimiter := SanitizeString(strings.TrimSuffix(string(output), "\n"), replacments)
-	return outPutWithDelimiter, nil
+	return outPutWithDelimiter, 0, nil
 }
 
+// FindNodeType find node type from running processes, static pod manifests and k3s/rke2 server markers
 func (e *cmd) FindNodeType() (string, error) {
-	masterConfigFiles := []string{
-		"ls /etc/kubernetes/controller-manager.conf",
-		"ls /etc/kubernetes/manifests/kube-apiserver.yaml",
-		"ls /etc/kubernetes/scheduler.conf",
-	}
-	for _, path := range masterConfigFiles {
-		output, err := e.Execute(path)
-		if err != nil {
-			return WorkerNode, nil
-		}
-		outputParts := strings.Split(output, ",")
-		if len(outputParts) > 0 {
-			for _, part := range outputParts {
-				if (len(strings.TrimSpace(part)) != 0 && !strings.Contains(path, strings.TrimSpace(part))) ||
-					len(strings.TrimSpace(part)) == 0 ||
-					err != nil {
-					return WorkerNode, nil
-				}
-			}
-		}
-	}
-	return MasterNode, nil
+	return nodeType(normalizeRoles(localNodeRoles(e))), nil
 }
@@ -0,0 +1,129 @@
+package collector
+
+import (
+	"crypto/ed25519"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/pem"
+	"fmt"
+	"os"
+	"strings"
+)
+
+// PayloadVerifier verify ed25519 detached signatures of decoded payloads
+type PayloadVerifier struct {
+	keys     []ed25519.PublicKey
+	required bool
+}
+
+// NewPayloadVerifier instansiate new payload verifier, when required is set
+// unsigned payloads are rejected
+func NewPayloadVerifier(keys []ed25519.PublicKey, required bool) *PayloadVerifier {
+	return &PayloadVerifier{keys: keys, required: required}
+}
+
+// Verify check the base64 encoded signature of payload against the configured public keys.
+// an empty signature is accepted unless signatures are required
+func (pv *PayloadVerifier) Verify(name string, payload []byte, signature string) error {
+	signature = strings.TrimSpace(signature)
+	if signature == "" {
+		if pv.required {
+			return fmt.Errorf("%s payload is not signed and signature is required", name)
+		}
+		return nil
+	}
+	if len(pv.keys) == 0 {
+		return fmt.Errorf("%s payload is signed but no public key has been provided", name)
+	}
+	sig, err := base64.StdEncoding.DecodeString(signature)
+	if err != nil {
+		return fmt.Errorf("failed to decode %s signature: %w", name, err)
+	}
+	if len(sig) != ed25519.SignatureSize {
+		return fmt.Errorf("invalid %s signature size %d", name, len(sig))
+	}
+	for _, key := range pv.keys {
+		if ed25519.Verify(key, payload, sig) {
+			return nil
+		}
+	}
+	return fmt.Errorf("%s signature verification failed", name)
+}
+
+// LoadPublicKeys load ed25519 public keys encoded to base64 or read from PEM / base64 key files
+func LoadPublicKeys(encodedKeys []string, keyFiles []string) ([]ed25519.PublicKey, error) {
+	keys := make([]ed25519.PublicKey, 0)
+	for _, ek := range encodedKeys {
+		key, err := decodePublicKey(ek)
+		if err != nil {
+			return nil, err
+		}
+		keys = append(keys, key)
+	}
+	for _, kf := range keyFiles {
+		data, err := os.ReadFile(kf)
+		if err != nil {
+			return nil, err
+		}
+		fileKeys, err := parsePublicKeys(data)
+		if err != nil {
+			return nil, fmt.Errorf("failed to parse public key file %s: %w", kf, err)
+		}
+		keys = append(keys, fileKeys...)
+	}
+	return keys, nil
+}
+
+// parsePublicKeys parse PEM encoded PKIX public keys or base64 encoded raw keys (one per line)
+func parsePublicKeys(data []byte) ([]ed25519.PublicKey, error) {
+	keys := make([]ed25519.PublicKey, 0)
+	if strings.Contains(string(data), "-----BEGIN") {
+		rest := data
+		for {
+			var block *pem.Block
+			block, rest = pem.Decode(rest)
+			if block == nil {
+				break
+			}
+			if block.Type != "PUBLIC KEY" {
+				continue
+			}
+			pub, err := x509.ParsePKIXPublicKey(block.Bytes)
+			if err != nil {
+				return nil, err
+			}
+			key, ok := pub.(ed25519.PublicKey)
+			if !ok {
+				return nil, fmt.Errorf("public key type %T is not supported", pub)
+			}
+			keys = append(keys, key)
+		}
+	} else {
+		for _, line := range strings.Split(string(data), "\n") {
+			line = strings.TrimSpace(line)
+			if line == "" || strings.HasPrefix(line, "#") {
+				continue
+			}
+			key, err := decodePublicKey(line)
+			if err != nil {
+				return nil, err
+			}
+			keys = append(keys, key)
+		}
+	}
+	if len(keys) == 0 {
+		return nil, fmt.Errorf("no public key found")
+	}
+	return keys, nil
+}
+
+func decodePublicKey(encodedKey string) (ed25519.PublicKey, error) {
+	data, err := base64.StdEncoding.DecodeString(strings.TrimSpace(encodedKey))
+	if err != nil {
+		return nil, fmt.Errorf("failed to decode public key: %w", err)
+	}
+	if len(data) != ed25519.PublicKeySize {
+		return nil, fmt.Errorf("invalid public key size %d", len(data))
+	}
+	return ed25519.PublicKey(data), nil
+}
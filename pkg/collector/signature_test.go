@@ -0,0 +1,116 @@
+package collector
+
+import (
+	"crypto/ed25519"
+	"crypto/rand"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/pem"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestPayloadVerifier(t *testing.T) {
+	pub, priv, err := ed25519.GenerateKey(rand.Reader)
+	assert.NoError(t, err)
+	otherPub, _, err := ed25519.GenerateKey(rand.Reader)
+	assert.NoError(t, err)
+	payload := []byte("commands:\n  - key: test\n    audit: ls /etc\n")
+	signature := base64.StdEncoding.EncodeToString(ed25519.Sign(priv, payload))
+
+	tests := []struct {
+		name      string
+		keys      []ed25519.PublicKey
+		required  bool
+		payload   []byte
+		signature string
+		wantErr   bool
+	}{
+		{
+			name:      "valid signature",
+			keys:      []ed25519.PublicKey{pub},
+			payload:   payload,
+			signature: signature,
+		},
+		{
+			name:      "valid signature with multiple keys",
+			keys:      []ed25519.PublicKey{otherPub, pub},
+			payload:   payload,
+			signature: signature,
+		},
+		{
+			name:      "tampered payload",
+			keys:      []ed25519.PublicKey{pub},
+			payload:   []byte("commands:\n  - key: test\n    audit: rm -rf /\n"),
+			signature: signature,
+			wantErr:   true,
+		},
+		{
+			name:      "unknown key",
+			keys:      []ed25519.PublicKey{otherPub},
+			payload:   payload,
+			signature: signature,
+			wantErr:   true,
+		},
+		{
+			name:      "signed payload without keys",
+			payload:   payload,
+			signature: signature,
+			wantErr:   true,
+		},
+		{
+			name:    "unsigned payload allowed",
+			keys:    []ed25519.PublicKey{pub},
+			payload: payload,
+		},
+		{
+			name:     "unsigned payload required",
+			keys:     []ed25519.PublicKey{pub},
+			required: true,
+			payload:  payload,
+			wantErr:  true,
+		},
+		{
+			name:      "malformed signature",
+			keys:      []ed25519.PublicKey{pub},
+			payload:   payload,
+			signature: base64.StdEncoding.EncodeToString([]byte("short")),
+			wantErr:   true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := NewPayloadVerifier(tt.keys, tt.required).Verify("node-commands", tt.payload, tt.signature)
+			if tt.wantErr {
+				assert.Error(t, err)
+			} else {
+				assert.NoError(t, err)
+			}
+		})
+	}
+}
+
+func TestLoadPublicKeys(t *testing.T) {
+	pub, _, err := ed25519.GenerateKey(rand.Reader)
+	assert.NoError(t, err)
+	der, err := x509.MarshalPKIXPublicKey(pub)
+	assert.NoError(t, err)
+	dir := t.TempDir()
+	pemFile := filepath.Join(dir, "key.pem")
+	err = os.WriteFile(pemFile, pem.EncodeToMemory(&pem.Block{Type: "PUBLIC KEY", Bytes: der}), 0600)
+	assert.NoError(t, err)
+	rawFile := filepath.Join(dir, "keys.txt")
+	err = os.WriteFile(rawFile, []byte("# signing key\n"+base64.StdEncoding.EncodeToString(pub)+"\n"), 0600)
+	assert.NoError(t, err)
+
+	keys, err := LoadPublicKeys([]string{base64.StdEncoding.EncodeToString(pub)}, []string{pemFile, rawFile})
+	assert.NoError(t, err)
+	assert.Equal(t, []ed25519.PublicKey{pub, pub, pub}, keys)
+
+	_, err = LoadPublicKeys([]string{"bm90IGEga2V5"}, nil)
+	assert.Error(t, err)
+}
@@ -0,0 +1,339 @@
+package collector
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"path"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/olekukonko/tablewriter"
+	"github.com/spf13/cobra"
+	"gopkg.in/yaml.v3"
+)
+
+// SpecSummary embedded spec catalog entry
+type SpecSummary struct {
+	Name      string   `json:"name"`
+	Version   string   `json:"version"`
+	Title     string   `json:"title"`
+	Platforms []string `json:"platforms"`
+	Commands  int      `json:"commands"`
+}
+
+// CommandExplain spec command details with the kubelet config mapping and the variables it depends on
+type CommandExplain struct {
+	Spec      string            `json:"spec"`
+	Key       string            `json:"key"`
+	Title     string            `json:"title"`
+	Audit     string            `json:"audit"`
+	NodeType  string            `json:"nodeType"`
+	Platforms []string          `json:"platforms,omitempty"`
+	Mapping   string            `json:"mapping,omitempty"`
+	Variables []CommandVariable `json:"variables,omitempty"`
+}
+
+// CommandVariable command variable with the node config candidate paths and default value
+type CommandVariable struct {
+	Name       string   `json:"name"`
+	Candidates []string `json:"candidates,omitempty"`
+	Default    string   `json:"default,omitempty"`
+}
+
+// defaultsShell shell which never find anything on host, node config lookups resolve to their default values
+type defaultsShell struct{}
+
+func (defaultsShell) Execute(string) (string, error) { return "", nil }
+
+func (defaultsShell) Run(string) (string, int, error) { return "", 0, nil }
+
+func (defaultsShell) FindNodeType() (string, error) { return MasterNode, nil }
+
+// ListSpecs print embedded specs name, version, platforms and command count
+func ListSpecs(cmd *cobra.Command) error {
+	var mapper Mapper
+	if err := yaml.Unmarshal(defaultNodeConfig, &mapper); err != nil {
+		return err
+	}
+	summaries, err := listSpecs(mapper)
+	if err != nil {
+		return err
+	}
+	w := cmd.OutOrStdout()
+	if cmd.Flag("output").Value.String() == "table" {
+		data := make([][]string, 0, len(summaries))
+		for _, s := range summaries {
+			data = append(data, []string{s.Name, s.Version, strings.Join(s.Platforms, ","), strconv.Itoa(s.Commands)})
+		}
+		table := tablewriter.NewWriter(w)
+		table.SetHeader([]string{"Name", "Version", "Platforms", "Commands"})
+		table.SetBorder(false)
+		table.AppendBulk(data)
+		table.Render()
+		return nil
+	}
+	return printJSON(w, summaries)
+}
+
+// ShowSpec print embedded spec commands with variables resolved to node config default values
+func ShowSpec(cmd *cobra.Command, name string) error {
+	payloads, err := newPayloadLoader(cmd)
+	if err != nil {
+		return err
+	}
+	config, err := specNodeConfig(cmd, payloads)
+	if err != nil {
+		return err
+	}
+	spec, err := readSpec(name)
+	if err != nil {
+		return err
+	}
+	commands := resolveCommands(spec.Commands, configParams(config, defaultsShell{}))
+	w := cmd.OutOrStdout()
+	if cmd.Flag("output").Value.String() == "table" {
+		data := make([][]string, 0, len(commands))
+		for _, c := range commands {
+			data = append(data, []string{c.Key, c.NodeType, c.Audit})
+		}
+		table := tablewriter.NewWriter(w)
+		table.SetHeader([]string{"Key", "Node Type", "Audit"})
+		table.SetBorder(false)
+		table.SetAutoWrapText(false)
+		table.AppendBulk(data)
+		table.Render()
+		return nil
+	}
+	spec.Commands = commands
+	return printJSON(w, spec)
+}
+
+// ExplainCommand print title, audit, node type, kubelet config mapping and variables of a command key
+// in every embedded spec defining it
+func ExplainCommand(cmd *cobra.Command, key string) error {
+	payloads, err := newPayloadLoader(cmd)
+	if err != nil {
+		return err
+	}
+	config, err := specNodeConfig(cmd, payloads)
+	if err != nil {
+		return err
+	}
+	mappingData, err := payloads.load(cmd, "kubelet-config-mapping")
+	if err != nil {
+		return err
+	}
+	if len(mappingData) == 0 {
+		mappingData = defaultKubeletConfigMapping
+	}
+	mapping, err := parseKubeletMapping(mappingData)
+	if err != nil {
+		return err
+	}
+	explains, err := explainCommand(key, config, mapping)
+	if err != nil {
+		return err
+	}
+	w := cmd.OutOrStdout()
+	if cmd.Flag("output").Value.String() == "table" {
+		for i, e := range explains {
+			if i > 0 {
+				fmt.Fprintln(w)
+			}
+			printExplain(w, e)
+		}
+		return nil
+	}
+	return printJSON(w, explains)
+}
+
+// specNodeConfig node config from node-config flag, default to built-in node config
+func specNodeConfig(cmd *cobra.Command, payloads *payloadLoader) (*Config, error) {
+	data, err := payloads.load(cmd, "node-config")
+	if err != nil {
+		return nil, err
+	}
+	if len(data) == 0 {
+		data = defaultNodeConfig
+	}
+	return parseConfigParams(data)
+}
+
+// specNames embedded specs name sorted, example: k8s-cis-1.23.0
+func specNames() ([]string, error) {
+	entries, err := specsFS.ReadDir(specsDir)
+	if err != nil {
+		return nil, err
+	}
+	names := make([]string, 0, len(entries))
+	for _, e := range entries {
+		if e.IsDir() || path.Ext(e.Name()) != ".yaml" {
+			continue
+		}
+		names = append(names, strings.TrimSuffix(e.Name(), ".yaml"))
+	}
+	sort.Strings(names)
+	return names, nil
+}
+
+func readSpec(name string) (*SpecInfo, error) {
+	data, err := loadSpec(name)
+	if err != nil {
+		return nil, err
+	}
+	var spec SpecInfo
+	if err := yaml.Unmarshal(data, &spec); err != nil {
+		return nil, fmt.Errorf("failed to parse spec %s: %w", name, err)
+	}
+	return &spec, nil
+}
+
+func listSpecs(mapper Mapper) ([]SpecSummary, error) {
+	names, err := specNames()
+	if err != nil {
+		return nil, err
+	}
+	summaries := make([]SpecSummary, 0, len(names))
+	for _, name := range names {
+		spec, err := readSpec(name)
+		if err != nil {
+			return nil, err
+		}
+		summaries = append(summaries, SpecSummary{
+			Name:      spec.Name,
+			Version:   spec.Version,
+			Title:     spec.Title,
+			Platforms: specPlatforms(spec, mapper),
+			Commands:  len(spec.Commands),
+		})
+	}
+	return summaries, nil
+}
+
+// specPlatforms union of spec commands platforms and of version mapping platforms using the spec,
+// default to spec name prefix, example: k8s-cis => k8s
+func specPlatforms(spec *SpecInfo, mapper Mapper) []string {
+	set := make(map[string]bool)
+	for _, c := range spec.Commands {
+		for _, p := range c.Platforms {
+			set[p] = true
+		}
+	}
+	for platform, versions := range mapper.VersionMapping {
+		for _, v := range versions {
+			if v.CisSpecName == spec.Name && v.CisSpecVersion == spec.Version {
+				set[platform] = true
+			}
+		}
+	}
+	if len(set) == 0 {
+		prefix := strings.SplitN(spec.Name, "-", 2)[0]
+		if contains(platforms, prefix) {
+			set[prefix] = true
+		}
+	}
+	result := make([]string, 0, len(set))
+	for p := range set {
+		result = append(result, p)
+	}
+	sort.Strings(result)
+	return result
+}
+
+// resolveCommands replace commands audit variables with their param value, unknown variables are kept as is
+func resolveCommands(commands []Command, params map[string]string) []Command {
+	resolved := make([]Command, 0, len(commands))
+	for _, c := range commands {
+		c.Audit = variableRe.ReplaceAllStringFunc(c.Audit, func(v string) string {
+			if value, ok := params[v]; ok {
+				return value
+			}
+			return v
+		})
+		resolved = append(resolved, c)
+	}
+	return resolved
+}
+
+func explainCommand(key string, config *Config, mapping map[string]string) ([]CommandExplain, error) {
+	names, err := specNames()
+	if err != nil {
+		return nil, err
+	}
+	params := configParams(config, defaultsShell{})
+	components := config.Node.Components()
+	explains := make([]CommandExplain, 0)
+	for _, name := range names {
+		spec, err := readSpec(name)
+		if err != nil {
+			return nil, err
+		}
+		for _, c := range spec.Commands {
+			if c.Key != key {
+				continue
+			}
+			e := CommandExplain{
+				Spec:      name,
+				Key:       c.Key,
+				Title:     c.Title,
+				Audit:     c.Audit,
+				NodeType:  c.NodeType,
+				Platforms: c.Platforms,
+				Mapping:   mapping[c.Key],
+			}
+			seen := make(map[string]bool)
+			for _, m := range variableRe.FindAllStringSubmatch(c.Audit, -1) {
+				if seen[m[0]] {
+					continue
+				}
+				seen[m[0]] = true
+				v := CommandVariable{Name: m[0], Default: params[m[0]]}
+				if lookup, ok := variableParams[m[2]]; ok {
+					if p, ok := components[m[1]]; ok {
+						v.Candidates = lookup(p)
+					}
+				}
+				e.Variables = append(e.Variables, v)
+			}
+			explains = append(explains, e)
+		}
+	}
+	if len(explains) == 0 {
+		return nil, fmt.Errorf("command %s not found in specs", key)
+	}
+	return explains, nil
+}
+
+func printExplain(w io.Writer, e CommandExplain) {
+	fmt.Fprintf(w, "Key:       %s\n", e.Key)
+	fmt.Fprintf(w, "Spec:      %s\n", e.Spec)
+	fmt.Fprintf(w, "Title:     %s\n", e.Title)
+	fmt.Fprintf(w, "Node Type: %s\n", e.NodeType)
+	if len(e.Platforms) > 0 {
+		fmt.Fprintf(w, "Platforms: %s\n", strings.Join(e.Platforms, ","))
+	}
+	fmt.Fprintf(w, "Audit:     %s\n", e.Audit)
+	if e.Mapping != "" {
+		fmt.Fprintf(w, "Mapping:   %s\n", e.Mapping)
+	}
+	if len(e.Variables) > 0 {
+		fmt.Fprintln(w, "Variables:")
+		for _, v := range e.Variables {
+			fmt.Fprintf(w, "  %s (default: %s)\n", v.Name, v.Default)
+			for _, c := range v.Candidates {
+				fmt.Fprintf(w, "    - %s\n", c)
+			}
+		}
+	}
+}
+
+func printJSON(w io.Writer, v interface{}) error {
+	data, err := json.MarshalIndent(v, "", "  ")
+	if err != nil {
+		return err
+	}
+	fmt.Fprintln(w, string(data))
+	return nil
+}
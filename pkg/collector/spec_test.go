@@ -0,0 +1,93 @@
+package collector
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"gopkg.in/yaml.v3"
+)
+
+func TestListSpecs(t *testing.T) {
+	tests := []struct {
+		name   string
+		spec   SpecInfo
+		mapper Mapper
+		want   []string
+	}{
+		{
+			name: "commands platforms",
+			spec: SpecInfo{Name: "k8s-cis", Version: "1.23.0", Commands: []Command{{Key: "a", Platforms: []string{"rke2", "k3s"}}, {Key: "b", Platforms: []string{"k3s"}}}},
+			want: []string{"k3s", "rke2"},
+		},
+		{
+			name:   "version mapping platforms",
+			spec:   SpecInfo{Name: "k8s-cis", Version: "1.23.0"},
+			mapper: Mapper{VersionMapping: map[string][]SpecVersion{native: {{CisSpecName: "k8s-cis", CisSpecVersion: "1.23.0"}}, gke: {{CisSpecName: "gke-cis", CisSpecVersion: "1.2.0"}}}},
+			want:   []string{"k8s"},
+		},
+		{
+			name: "spec name prefix",
+			spec: SpecInfo{Name: "eks-cis", Version: "1.2.0"},
+			want: []string{"eks"},
+		},
+		{
+			name: "unknown platform",
+			spec: SpecInfo{Name: "custom", Version: "1.0"},
+			want: []string{},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.want, specPlatforms(&tt.spec, tt.mapper))
+		})
+	}
+
+	data, err := loadSpec(defaultSpec)
+	assert.NoError(t, err)
+	var spec SpecInfo
+	assert.NoError(t, yaml.Unmarshal(data, &spec))
+	summaries, err := listSpecs(Mapper{})
+	assert.NoError(t, err)
+	if assert.Len(t, summaries, 1) {
+		assert.Equal(t, "k8s-cis", summaries[0].Name)
+		assert.Equal(t, "1.23.0", summaries[0].Version)
+		assert.Equal(t, []string{"k8s"}, summaries[0].Platforms)
+		assert.Equal(t, len(spec.Commands), summaries[0].Commands)
+	}
+}
+
+func TestResolveCommands(t *testing.T) {
+	config := &Config{Node: NodeParams{KubeLet: Params{
+		Config:            []string{"/var/lib/kubelet/config.yaml", "/etc/kubernetes/kubelet-config.yaml"},
+		DefaultConfig:     "/var/lib/kubelet/config.yaml",
+		KubeConfig:        []string{"/etc/kubernetes/kubelet.conf"},
+		DefaultKubeConfig: "/etc/kubernetes/kubelet.conf",
+	}}}
+	commands := []Command{
+		{Key: "kubeletConfFilePermissions", Audit: "stat -c %a $kubelet.kubeconfig"},
+		{Key: "kubeletConfigYamlConfigurationFilePermission", Audit: "stat -c %a $kubelet.confs $kubelet.cafile"},
+	}
+	want := []Command{
+		{Key: "kubeletConfFilePermissions", Audit: "stat -c %a /etc/kubernetes/kubelet.conf"},
+		{Key: "kubeletConfigYamlConfigurationFilePermission", Audit: "stat -c %a /var/lib/kubelet/config.yaml $kubelet.cafile"},
+	}
+	assert.Equal(t, want, resolveCommands(commands, configParams(config, defaultsShell{})))
+}
+
+func TestExplainCommand(t *testing.T) {
+	config, err := parseConfigParams(defaultNodeConfig)
+	assert.NoError(t, err)
+	mapping, err := parseKubeletMapping(defaultKubeletConfigMapping)
+	assert.NoError(t, err)
+
+	explains, err := explainCommand("kubeletAnonymousAuthArgumentSet", config, mapping)
+	assert.NoError(t, err)
+	assert.Len(t, explains, 1)
+	assert.Equal(t, "k8s-cis-1.23.0", explains[0].Spec)
+	assert.Equal(t, WorkerNode, explains[0].NodeType)
+	assert.Equal(t, "kubeletconfig.authentication.anonymous.enabled", explains[0].Mapping)
+	assert.Equal(t, []CommandVariable{{Name: "$kubelet.bins", Candidates: []string{"hyperkube kubelet", "kubelet"}}}, explains[0].Variables)
+
+	_, err = explainCommand("unknownKey", config, mapping)
+	assert.Error(t, err)
+}
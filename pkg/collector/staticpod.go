@@ -0,0 +1,225 @@
+package collector
+
+import (
+	"bufio"
+	"bytes"
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strings"
+
+	corev1 "k8s.io/api/core/v1"
+	"sigs.k8s.io/yaml"
+)
+
+const (
+	// ProbeStaticPod parse control-plane component static pod manifest or args file into a flag map
+	ProbeStaticPod = "staticpod"
+	// ProbeFlag lookup a control-plane component or kubelet flag value, example: apiserver.flags["--anonymous-auth"]
+	ProbeFlag = "flag"
+
+	kubeletComponent = "kubelet"
+)
+
+var (
+	flagRefRe = regexp.MustCompile(`^([a-z]+)\.flags\[\s*["']?(--?[\w.\-]+)["']?\s*\]$`)
+
+	// componentProcesses component static pod container or process name
+	componentProcesses = map[string]string{
+		"apiserver":         "kube-apiserver",
+		"controllermanager": "kube-controller-manager",
+		"scheduler":         "kube-scheduler",
+		"etcd":              "etcd",
+		"proxy":             "kube-proxy",
+	}
+)
+
+// StaticPodInfo control-plane component command line and mounts from static pod manifest or args file
+type StaticPodInfo struct {
+	Component    string            `json:"component"`
+	Path         string            `json:"path"`
+	Image        string            `json:"image,omitempty"`
+	Flags        map[string]string `json:"flags"`
+	VolumeMounts []VolumeMount     `json:"volumeMounts,omitempty"`
+}
+
+// VolumeMount static pod container mount and its host path
+type VolumeMount struct {
+	Name      string `json:"name"`
+	MountPath string `json:"mountPath"`
+	HostPath  string `json:"hostPath,omitempty"`
+	ReadOnly  bool   `json:"readOnly,omitempty"`
+}
+
+// staticPodProbe parse comma separated components config ($<component>.confs), example: apiserver,etcd
+func staticPodProbe(ctx ProbeContext, arg string) ([]interface{}, error) {
+	values := make([]interface{}, 0)
+	for _, component := range strings.Split(arg, ",") {
+		component = strings.TrimSpace(component)
+		if component == "" {
+			continue
+		}
+		if _, ok := componentProcesses[component]; !ok {
+			return nil, fmt.Errorf("unknown component %q", component)
+		}
+		info, ok := loadStaticPod(ctx, component)
+		if ok {
+			values = append(values, info)
+		}
+	}
+	return values, nil
+}
+
+// flagProbe lookup component flag value, comma separated values are reported as multiple values as shell output
+func flagProbe(ctx ProbeContext, arg string) ([]interface{}, error) {
+	m := flagRefRe.FindStringSubmatch(strings.TrimSpace(arg))
+	if m == nil {
+		return nil, fmt.Errorf("invalid flag reference %q, expected <component>.flags[\"--flag\"]", arg)
+	}
+	flags, err := componentFlags(ctx, m[1])
+	if err != nil {
+		return nil, err
+	}
+	value, ok := flags[m[2]]
+	if !ok {
+		return []interface{}{}, nil
+	}
+	return probeValues(strings.Split(value, ",")), nil
+}
+
+// componentFlags component flag map, kubelet flags are read from its systemd unit
+func componentFlags(ctx ProbeContext, component string) (map[string]string, error) {
+	if component == kubeletComponent {
+		info, _ := loadSystemdUnit(ctx.Root, systemdUnitName(component))
+		return info.Flags, nil
+	}
+	if _, ok := componentProcesses[component]; !ok {
+		return nil, fmt.Errorf("unknown component %q", component)
+	}
+	info, _ := loadStaticPod(ctx, component)
+	return info.Flags, nil
+}
+
+// loadStaticPod parse component config resolved by node config, a static pod manifest or an args file with a flag per line
+func loadStaticPod(ctx ProbeContext, component string) (StaticPodInfo, bool) {
+	path := ctx.Params[fmt.Sprintf("$%s.confs", component)]
+	if path == "" {
+		return StaticPodInfo{}, false
+	}
+	data, err := os.ReadFile(filepath.Join(ctx.Root, path))
+	if err != nil {
+		return StaticPodInfo{}, false
+	}
+	info := StaticPodInfo{Component: component, Path: path}
+	if pod, ok := parseStaticPod(data); ok {
+		container, ok := componentContainer(pod, componentProcesses[component])
+		if !ok {
+			return StaticPodInfo{}, false
+		}
+		info.Image = container.Image
+		info.Flags = parseFlags(containerArgs(container), false)
+		info.VolumeMounts = volumeMounts(pod, container)
+		return info, true
+	}
+	info.Flags = parseFlags(argsFileFlags(data), false)
+	return info, true
+}
+
+// parseStaticPod parse data as pod manifest
+func parseStaticPod(data []byte) (corev1.Pod, bool) {
+	var pod corev1.Pod
+	if err := yaml.Unmarshal(data, &pod); err != nil || pod.Kind != "Pod" {
+		return corev1.Pod{}, false
+	}
+	return pod, true
+}
+
+func containerArgs(container corev1.Container) []string {
+	return append(append([]string{}, container.Command...), container.Args...)
+}
+
+// probeStaticPod load the static pod of the probe arg component, default to apiserver. probes following
+// component config files report nothing when it can not be read
+func probeStaticPod(ctx ProbeContext, arg string) (StaticPodInfo, bool, error) {
+	component := strings.TrimSpace(arg)
+	if component == "" {
+		component = "apiserver"
+	}
+	if _, ok := componentProcesses[component]; !ok {
+		return StaticPodInfo{}, false, fmt.Errorf("unknown component %q", component)
+	}
+	info, ok := loadStaticPod(ctx, component)
+	return info, ok, nil
+}
+
+// componentContainer container named after component process, default to first container
+func componentContainer(pod corev1.Pod, process string) (corev1.Container, bool) {
+	if len(pod.Spec.Containers) == 0 {
+		return corev1.Container{}, false
+	}
+	for _, c := range pod.Spec.Containers {
+		if c.Name == process {
+			return c, true
+		}
+	}
+	return pod.Spec.Containers[0], true
+}
+
+func volumeMounts(pod corev1.Pod, container corev1.Container) []VolumeMount {
+	hostPaths := make(map[string]string)
+	for _, v := range pod.Spec.Volumes {
+		if v.HostPath != nil {
+			hostPaths[v.Name] = v.HostPath.Path
+		}
+	}
+	mounts := make([]VolumeMount, 0, len(container.VolumeMounts))
+	for _, m := range container.VolumeMounts {
+		mounts = append(mounts, VolumeMount{Name: m.Name, MountPath: m.MountPath, HostPath: hostPaths[m.Name], ReadOnly: m.ReadOnly})
+	}
+	sort.Slice(mounts, func(i, j int) bool { return mounts[i].MountPath < mounts[j].MountPath })
+	return mounts
+}
+
+// argsFileFlags args file tokens, example: microk8s /var/snap/microk8s/current/args/kube-apiserver.
+// an args file line holds one flag, a --flag value line is read as --flag=value
+func argsFileFlags(data []byte) []string {
+	args := make([]string, 0)
+	scanner := bufio.NewScanner(bytes.NewReader(data))
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+		if i := strings.IndexAny(line, " \t"); i > 0 && strings.HasPrefix(line, "-") && !strings.Contains(line[:i], "=") {
+			line = line[:i] + "=" + strings.TrimSpace(line[i:])
+		}
+		args = append(args, line)
+	}
+	return args
+}
+
+// parseFlags flag map of command line, --flag=value form and --flag value form when separateValues is set,
+// flags without value are set to true. manifests and args files set values with =, a bare flag followed by a
+// positional argument is a boolean flag there. the last occurrence of a repeated flag wins as for components flags parsing
+func parseFlags(args []string, separateValues bool) map[string]string {
+	flags := make(map[string]string)
+	for i := 0; i < len(args); i++ {
+		arg := args[i]
+		if !strings.HasPrefix(arg, "-") || strings.Trim(arg, "-") == "" {
+			continue
+		}
+		if name, value, ok := strings.Cut(arg, "="); ok {
+			flags[name] = strings.Trim(value, `"'`)
+			continue
+		}
+		if separateValues && i+1 < len(args) && !strings.HasPrefix(args[i+1], "-") {
+			flags[arg] = strings.Trim(args[i+1], `"'`)
+			i++
+			continue
+		}
+		flags[arg] = "true"
+	}
+	return flags
+}
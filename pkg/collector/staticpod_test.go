@@ -0,0 +1,156 @@
+package collector
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+const apiserverManifest = `apiVersion: v1
+kind: Pod
+metadata:
+  name: kube-apiserver
+  namespace: kube-system
+spec:
+  containers:
+  - name: kube-apiserver
+    image: registry.k8s.io/kube-apiserver:v1.29.0
+    command:
+    - kube-apiserver
+    - --anonymous-auth=false
+    - --authorization-mode=Node,RBAC
+    - --profiling
+    - --secure-port=6443
+    volumeMounts:
+    - mountPath: /etc/kubernetes/pki
+      name: k8s-certs
+      readOnly: true
+    - mountPath: /etc/ssl/certs
+      name: ca-certs
+      readOnly: true
+  volumes:
+  - hostPath:
+      path: /etc/kubernetes/pki
+      type: DirectoryOrCreate
+    name: k8s-certs
+  - hostPath:
+      path: /etc/ssl/certs
+    name: ca-certs
+`
+
+func TestStaticPodProbe(t *testing.T) {
+	root := writeFiles(t, map[string]string{
+		"/etc/kubernetes/manifests/kube-apiserver.yaml":  apiserverManifest,
+		"/var/snap/microk8s/current/args/kube-scheduler": "# scheduler args\n--leader-elect=true\n--kubeconfig=${SNAP_DATA}/credentials/scheduler.config\n--profiling false\n",
+	})
+	ctx := ProbeContext{Root: root, Params: map[string]string{
+		"$apiserver.confs":         "/etc/kubernetes/manifests/kube-apiserver.yaml",
+		"$controllermanager.confs": "/etc/kubernetes/manifests/kube-controller-manager.yaml",
+		"$scheduler.confs":         "/var/snap/microk8s/current/args/kube-scheduler",
+	}}
+
+	got, err := staticPodProbe(ctx, "apiserver,controllermanager,scheduler")
+	assert.NoError(t, err)
+	assert.Equal(t, []interface{}{
+		StaticPodInfo{
+			Component: "apiserver",
+			Path:      "/etc/kubernetes/manifests/kube-apiserver.yaml",
+			Image:     "registry.k8s.io/kube-apiserver:v1.29.0",
+			Flags: map[string]string{
+				"--anonymous-auth":     "false",
+				"--authorization-mode": "Node,RBAC",
+				"--profiling":          "true",
+				"--secure-port":        "6443",
+			},
+			VolumeMounts: []VolumeMount{
+				{Name: "k8s-certs", MountPath: "/etc/kubernetes/pki", HostPath: "/etc/kubernetes/pki", ReadOnly: true},
+				{Name: "ca-certs", MountPath: "/etc/ssl/certs", HostPath: "/etc/ssl/certs", ReadOnly: true},
+			},
+		},
+		StaticPodInfo{
+			Component: "scheduler",
+			Path:      "/var/snap/microk8s/current/args/kube-scheduler",
+			Flags: map[string]string{
+				"--leader-elect": "true",
+				"--kubeconfig":   "${SNAP_DATA}/credentials/scheduler.config",
+				"--profiling":    "false",
+			},
+		},
+	}, got)
+
+	_, err = staticPodProbe(ctx, "apiserver,unknown")
+	assert.EqualError(t, err, `unknown component "unknown"`)
+}
+
+func TestFlagProbe(t *testing.T) {
+	root := writeFiles(t, map[string]string{
+		"/etc/kubernetes/manifests/kube-apiserver.yaml": apiserverManifest,
+	})
+	ctx := ProbeContext{Root: root, Params: map[string]string{
+		"$apiserver.confs": "/etc/kubernetes/manifests/kube-apiserver.yaml",
+		"$scheduler.confs": "/etc/kubernetes/manifests/kube-scheduler.yaml",
+	}}
+	tests := []struct {
+		name    string
+		arg     string
+		want    []interface{}
+		wantErr string
+	}{
+		{name: "flag value", arg: `apiserver.flags["--anonymous-auth"]`, want: []interface{}{"false"}},
+		{name: "comma separated values", arg: `apiserver.flags['--authorization-mode']`, want: []interface{}{"Node", "RBAC"}},
+		{name: "numeric value", arg: `apiserver.flags[--secure-port]`, want: []interface{}{6443}},
+		{name: "flag without value", arg: `apiserver.flags["--profiling"]`, want: []interface{}{"true"}},
+		{name: "missing flag", arg: `apiserver.flags["--audit-log-path"]`, want: []interface{}{}},
+		{name: "missing manifest", arg: `scheduler.flags["--profiling"]`, want: []interface{}{}},
+		{name: "unknown component", arg: `kubectl.flags["--v"]`, wantErr: `unknown component "kubectl"`},
+		{name: "invalid reference", arg: `apiserver.--anonymous-auth`, wantErr: `invalid flag reference "apiserver.--anonymous-auth", expected <component>.flags["--flag"]`},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := flagProbe(ctx, tt.arg)
+			if tt.wantErr != "" {
+				assert.EqualError(t, err, tt.wantErr)
+				return
+			}
+			assert.NoError(t, err)
+			assert.Equal(t, tt.want, got)
+		})
+	}
+}
+
+func TestParseFlags(t *testing.T) {
+	tests := []struct {
+		name           string
+		args           []string
+		separateValues bool
+		want           map[string]string
+	}{
+		{
+			name: "bare flag followed by positional argument",
+			args: []string{"kube-apiserver", "--profiling", "positional", "--secure-port=6443"},
+			want: map[string]string{"--profiling": "true", "--secure-port": "6443"},
+		},
+		{
+			name:           "separate flag value",
+			args:           []string{"kubelet", "--config", "/var/lib/kubelet/config.yaml", "--v", "2"},
+			separateValues: true,
+			want:           map[string]string{"--config": "/var/lib/kubelet/config.yaml", "--v": "2"},
+		},
+		{
+			name:           "bare flag followed by flag",
+			args:           []string{"kubelet", "--rotate-certificates", "--v=2"},
+			separateValues: true,
+			want:           map[string]string{"--rotate-certificates": "true", "--v": "2"},
+		},
+		{
+			name: "last occurrence wins",
+			args: []string{"--v=2", "--v=4"},
+			want: map[string]string{"--v": "4"},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.want, parseFlags(tt.args, tt.separateValues))
+		})
+	}
+}
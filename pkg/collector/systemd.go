@@ -0,0 +1,276 @@
+package collector
+
+import (
+	"bufio"
+	"bytes"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strings"
+)
+
+const (
+	// ProbeSystemd parse systemd service unit and drop-ins into the effective command line and flag map
+	ProbeSystemd = "systemd"
+
+	serviceSection = "Service"
+)
+
+var (
+	// systemdUnitDirs unit search path ordered by precedence
+	systemdUnitDirs = []string{
+		"/etc/systemd/system",
+		"/run/systemd/system",
+		"/lib/systemd/system",
+		"/usr/lib/systemd/system",
+	}
+
+	systemdVariableRe = regexp.MustCompile(`\$\{(\w+)\}|\$(\w+)`)
+)
+
+// SystemdUnitInfo service effective command line once unit, drop-ins and environment are merged.
+// environment values are not reported as they may hold credentials
+type SystemdUnitInfo struct {
+	Unit             string            `json:"unit"`
+	Path             string            `json:"path"`
+	DropIns          []string          `json:"dropIns,omitempty"`
+	EnvironmentFiles []string          `json:"environmentFiles,omitempty"`
+	CommandLine      string            `json:"commandLine"`
+	Flags            map[string]string `json:"flags"`
+}
+
+// systemdProbe parse comma separated service units by name or unit / drop-in path, example: kubelet,$kubelet.svc.
+// units which can not be found are skipped
+func systemdProbe(ctx ProbeContext, arg string) ([]interface{}, error) {
+	values := make([]interface{}, 0)
+	seen := make(map[string]bool)
+	for _, entry := range strings.Split(arg, ",") {
+		unit := systemdUnitName(strings.TrimSpace(entry))
+		if unit == "" || seen[unit] {
+			continue
+		}
+		seen[unit] = true
+		info, ok := loadSystemdUnit(ctx.Root, unit)
+		if ok {
+			values = append(values, info)
+		}
+	}
+	return values, nil
+}
+
+// systemdUnitName unit name of a name or path, example: kubelet, /lib/systemd/system/kubelet.service
+// or /etc/systemd/system/kubelet.service.d/10-kubeadm.conf all name kubelet.service
+func systemdUnitName(entry string) string {
+	if entry == "" {
+		return ""
+	}
+	if strings.Contains(entry, "/") {
+		if strings.HasSuffix(filepath.Dir(entry), ".d") {
+			return strings.TrimSuffix(filepath.Base(filepath.Dir(entry)), ".d")
+		}
+		entry = filepath.Base(entry)
+	}
+	if filepath.Ext(entry) == "" {
+		entry += ".service"
+	}
+	return entry
+}
+
+// loadSystemdUnit merge unit file and drop-ins in systemd order, expand environment and parse ExecStart flags
+func loadSystemdUnit(root string, unit string) (SystemdUnitInfo, bool) {
+	info := SystemdUnitInfo{Unit: unit}
+	for _, dir := range systemdUnitDirs {
+		path := filepath.Join(dir, unit)
+		if _, err := os.Stat(filepath.Join(root, path)); err == nil {
+			info.Path = path
+			break
+		}
+	}
+	info.DropIns = systemdDropIns(root, unit)
+	if info.Path == "" && len(info.DropIns) == 0 {
+		return SystemdUnitInfo{}, false
+	}
+	var execStart string
+	env := make(map[string]string)
+	envFiles := make([]string, 0)
+	files := info.DropIns
+	if info.Path != "" {
+		files = append([]string{info.Path}, files...)
+	}
+	for _, file := range files {
+		data, err := os.ReadFile(filepath.Join(root, file))
+		if err != nil {
+			continue
+		}
+		for _, d := range parseUnitFile(data, serviceSection) {
+			switch d.key {
+			case "ExecStart":
+				// an empty assignment reset previous ExecStart
+				execStart = d.value
+			case "Environment":
+				for _, assignment := range splitQuoted(d.value) {
+					if name, value, ok := strings.Cut(assignment, "="); ok {
+						env[name] = value
+					}
+				}
+			case "EnvironmentFile":
+				if d.value == "" {
+					envFiles = envFiles[:0]
+					continue
+				}
+				envFiles = append(envFiles, d.value)
+			}
+		}
+	}
+	// EnvironmentFile variables override Environment ones
+	for _, envFile := range envFiles {
+		path := strings.TrimPrefix(envFile, "-")
+		data, err := os.ReadFile(filepath.Join(root, path))
+		if err != nil {
+			continue
+		}
+		info.EnvironmentFiles = append(info.EnvironmentFiles, path)
+		for name, value := range parseEnvironmentFile(data) {
+			env[name] = value
+		}
+	}
+	args := expandExecStart(strings.TrimLeft(execStart, "-@+!:"), env)
+	info.CommandLine = strings.Join(args, " ")
+	// kubelet and other systemd managed components take no positional arguments, --flag value is a flag value
+	info.Flags = parseFlags(args, true)
+	return info, true
+}
+
+// systemdDropIns unit drop-in files sorted by file name, a drop-in masks same named drop-ins of lower precedence directories
+func systemdDropIns(root string, unit string) []string {
+	byName := make(map[string]string)
+	for i := len(systemdUnitDirs) - 1; i >= 0; i-- {
+		dir := filepath.Join(systemdUnitDirs[i], unit+".d")
+		matches, err := filepath.Glob(filepath.Join(root, dir, "*.conf"))
+		if err != nil {
+			continue
+		}
+		for _, m := range matches {
+			byName[filepath.Base(m)] = filepath.Join(dir, filepath.Base(m))
+		}
+	}
+	names := make([]string, 0, len(byName))
+	for name := range byName {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	dropIns := make([]string, 0, len(names))
+	for _, name := range names {
+		dropIns = append(dropIns, byName[name])
+	}
+	if len(dropIns) == 0 {
+		return nil
+	}
+	return dropIns
+}
+
+type unitDirective struct {
+	key   string
+	value string
+}
+
+// parseUnitFile section directives in file order, continuation lines ending with backslash are joined
+func parseUnitFile(data []byte, section string) []unitDirective {
+	directives := make([]unitDirective, 0)
+	current := ""
+	var line strings.Builder
+	scanner := bufio.NewScanner(bytes.NewReader(data))
+	for scanner.Scan() {
+		text := strings.TrimSpace(scanner.Text())
+		if line.Len() == 0 && (text == "" || strings.HasPrefix(text, "#") || strings.HasPrefix(text, ";")) {
+			continue
+		}
+		if strings.HasSuffix(text, `\`) {
+			line.WriteString(strings.TrimSuffix(text, `\`))
+			line.WriteString(" ")
+			continue
+		}
+		line.WriteString(text)
+		full := strings.TrimSpace(line.String())
+		line.Reset()
+		if strings.HasPrefix(full, "[") && strings.HasSuffix(full, "]") {
+			current = strings.Trim(full, "[]")
+			continue
+		}
+		if current != section {
+			continue
+		}
+		if key, value, ok := strings.Cut(full, "="); ok {
+			directives = append(directives, unitDirective{key: strings.TrimSpace(key), value: strings.TrimSpace(value)})
+		}
+	}
+	return directives
+}
+
+// parseEnvironmentFile KEY=VALUE lines, example: /var/lib/kubelet/kubeadm-flags.env
+func parseEnvironmentFile(data []byte) map[string]string {
+	env := make(map[string]string)
+	scanner := bufio.NewScanner(bytes.NewReader(data))
+	for scanner.Scan() {
+		text := strings.TrimSpace(scanner.Text())
+		if text == "" || strings.HasPrefix(text, "#") || strings.HasPrefix(text, ";") {
+			continue
+		}
+		if name, value, ok := strings.Cut(text, "="); ok {
+			env[strings.TrimSpace(name)] = strings.Trim(strings.TrimSpace(value), `"'`)
+		}
+	}
+	return env
+}
+
+// expandExecStart split command line and expand variables, a bare $VAR expand to its whitespace separated words
+// while ${VAR} expand to a single word as systemd does, unset variables expand to nothing
+func expandExecStart(execStart string, env map[string]string) []string {
+	args := make([]string, 0)
+	for _, word := range splitQuoted(execStart) {
+		if m := systemdVariableRe.FindStringSubmatch(word); m != nil && m[0] == word && m[2] != "" {
+			args = append(args, strings.Fields(env[m[2]])...)
+			continue
+		}
+		expanded := systemdVariableRe.ReplaceAllStringFunc(word, func(v string) string {
+			return env[strings.Trim(v, "${}")]
+		})
+		if expanded != "" {
+			args = append(args, expanded)
+		}
+	}
+	return args
+}
+
+// splitQuoted split on whitespace, single or double quoted words may hold whitespace and are unquoted
+func splitQuoted(s string) []string {
+	words := make([]string, 0)
+	var word strings.Builder
+	var quote rune
+	inWord := false
+	for _, r := range s {
+		switch {
+		case quote != 0 && r == quote:
+			quote = 0
+		case quote != 0:
+			word.WriteRune(r)
+		case r == '"' || r == '\'':
+			quote = r
+			inWord = true
+		case r == ' ' || r == '\t':
+			if inWord {
+				words = append(words, word.String())
+				word.Reset()
+				inWord = false
+			}
+		default:
+			word.WriteRune(r)
+			inWord = true
+		}
+	}
+	if inWord {
+		words = append(words, word.String())
+	}
+	return words
+}
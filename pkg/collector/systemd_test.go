@@ -0,0 +1,94 @@
+package collector
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestSystemdProbe(t *testing.T) {
+	root := writeFiles(t, map[string]string{
+		"/lib/systemd/system/kubelet.service": `[Unit]
+Description=kubelet: The Kubernetes Node Agent
+
+[Service]
+ExecStart=/usr/bin/kubelet
+Restart=always
+`,
+		"/lib/systemd/system/kubelet.service.d/10-kubeadm.conf": `[Service]
+Environment="KUBELET_KUBECONFIG_ARGS=--bootstrap-kubeconfig=/etc/kubernetes/bootstrap-kubelet.conf --kubeconfig=/etc/kubernetes/kubelet.conf"
+Environment="KUBELET_CONFIG_ARGS=--config=/var/lib/kubelet/config.yaml"
+# kubeadm generated flags
+EnvironmentFile=-/var/lib/kubelet/kubeadm-flags.env
+EnvironmentFile=-/etc/default/kubelet
+ExecStart=
+ExecStart=/usr/bin/kubelet $KUBELET_KUBECONFIG_ARGS $KUBELET_CONFIG_ARGS \
+  $KUBELET_KUBEADM_ARGS $KUBELET_EXTRA_ARGS
+`,
+		"/etc/systemd/system/kubelet.service.d/10-kubeadm.conf": `[Service]
+Environment="KUBELET_KUBECONFIG_ARGS=--kubeconfig=/etc/kubernetes/kubelet.conf"
+Environment="KUBELET_CONFIG_ARGS=--config=/var/lib/kubelet/config.yaml"
+EnvironmentFile=-/var/lib/kubelet/kubeadm-flags.env
+EnvironmentFile=-/etc/default/kubelet
+ExecStart=
+ExecStart=/usr/bin/kubelet $KUBELET_KUBECONFIG_ARGS $KUBELET_CONFIG_ARGS $KUBELET_KUBEADM_ARGS $KUBELET_EXTRA_ARGS
+`,
+		"/etc/systemd/system/kubelet.service.d/20-extra.conf": `[Service]
+Environment="KUBELET_EXTRA_ARGS=--node-ip=10.0.0.2 --read-only-port=0"
+`,
+		"/var/lib/kubelet/kubeadm-flags.env": `KUBELET_KUBEADM_ARGS="--container-runtime-endpoint=unix:///var/run/containerd/containerd.sock --pod-infra-container-image=registry.k8s.io/pause:3.9"
+`,
+		"/etc/systemd/system/containerd.service": `[Service]
+Environment=ROOT=/var/lib/containerd
+ExecStartPre=-/sbin/modprobe overlay
+ExecStart=-/usr/bin/containerd --root=${ROOT} --log-level info
+`,
+	})
+
+	got, err := systemdProbe(ProbeContext{Root: root}, "kubelet,/etc/systemd/system/kubelet.service.d/10-kubeadm.conf,containerd.service,missing")
+	assert.NoError(t, err)
+	assert.Equal(t, []interface{}{
+		SystemdUnitInfo{
+			Unit:             "kubelet.service",
+			Path:             "/lib/systemd/system/kubelet.service",
+			DropIns:          []string{"/etc/systemd/system/kubelet.service.d/10-kubeadm.conf", "/etc/systemd/system/kubelet.service.d/20-extra.conf"},
+			EnvironmentFiles: []string{"/var/lib/kubelet/kubeadm-flags.env"},
+			CommandLine: "/usr/bin/kubelet --kubeconfig=/etc/kubernetes/kubelet.conf --config=/var/lib/kubelet/config.yaml " +
+				"--container-runtime-endpoint=unix:///var/run/containerd/containerd.sock --pod-infra-container-image=registry.k8s.io/pause:3.9 " +
+				"--node-ip=10.0.0.2 --read-only-port=0",
+			Flags: map[string]string{
+				"--kubeconfig":                 "/etc/kubernetes/kubelet.conf",
+				"--config":                     "/var/lib/kubelet/config.yaml",
+				"--container-runtime-endpoint": "unix:///var/run/containerd/containerd.sock",
+				"--pod-infra-container-image":  "registry.k8s.io/pause:3.9",
+				"--node-ip":                    "10.0.0.2",
+				"--read-only-port":             "0",
+			},
+		},
+		SystemdUnitInfo{
+			Unit:        "containerd.service",
+			Path:        "/etc/systemd/system/containerd.service",
+			CommandLine: "/usr/bin/containerd --root=/var/lib/containerd --log-level info",
+			Flags: map[string]string{
+				"--root":      "/var/lib/containerd",
+				"--log-level": "info",
+			},
+		},
+	}, got)
+}
+
+func TestKubeletFlagProbe(t *testing.T) {
+	root := writeFiles(t, map[string]string{
+		"/etc/systemd/system/kubelet.service": `[Service]
+Environment="KUBELET_ARGS=--anonymous-auth=false --read-only-port=0"
+ExecStart=/usr/bin/kubelet $KUBELET_ARGS
+`,
+	})
+	got, err := flagProbe(ProbeContext{Root: root}, `kubelet.flags["--read-only-port"]`)
+	assert.NoError(t, err)
+	assert.Equal(t, []interface{}{0}, got)
+
+	got, err = flagProbe(ProbeContext{Root: t.TempDir()}, `kubelet.flags["--read-only-port"]`)
+	assert.NoError(t, err)
+	assert.Equal(t, []interface{}{}, got)
+}
@@ -0,0 +1,275 @@
+package collector
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"log/slog"
+	"os"
+	"os/signal"
+	"path/filepath"
+	"sort"
+	"strings"
+	"syscall"
+	"time"
+
+	"github.com/spf13/cobra"
+)
+
+const (
+	changeCreated  = "created"
+	changeDeleted  = "deleted"
+	changeModified = "modified"
+)
+
+// WatchEvent watched path change with the values of the commands referencing it
+type WatchEvent struct {
+	Time   string           `json:"time"`
+	Path   string           `json:"path"`
+	Change string           `json:"change"`
+	Hash   string           `json:"hash,omitempty"`
+	Info   map[string]*Info `json:"info,omitempty"`
+}
+
+// pathState watched path state, content hash is only computed for regular files
+type pathState struct {
+	exists  bool
+	inode   uint64
+	size    int64
+	modTime time.Time
+	mode    os.FileMode
+	uid     uint32
+	gid     uint32
+	hash    string
+}
+
+// Watch poll node config paths and print a NDJSON event for each change with the re-run commands referencing the path
+func Watch(cmd *cobra.Command) error {
+	interval, err := cmd.Flags().GetDuration("interval")
+	if err != nil {
+		return err
+	}
+	if interval <= 0 {
+		return fmt.Errorf("interval must be positive, got %s", interval)
+	}
+	ctx, stop := signal.NotifyContext(cmd.Context(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+	nc, err := newNodeCollector(ctx, cmd, false)
+	if err != nil {
+		return err
+	}
+	w, err := newWatcher(nc)
+	if err != nil {
+		return err
+	}
+	slog.Info("watching node config paths", "paths", len(w.paths), "interval", interval)
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return nil
+		case <-ticker.C:
+		}
+		if err := w.poll(ctx, os.Stdout); err != nil {
+			return err
+		}
+	}
+}
+
+// watcher node config paths state and the components configured with each path
+type watcher struct {
+	nc         *nodeCollector
+	config     *Config
+	paths      []string
+	states     map[string]pathState
+	components map[string][]string
+}
+
+func newWatcher(nc *nodeCollector) (*watcher, error) {
+	config, err := parseConfigParams(nc.nodeConfig)
+	if err != nil {
+		return nil, err
+	}
+	w := &watcher{nc: nc, config: config, states: make(map[string]pathState)}
+	w.refreshPaths()
+	return w, nil
+}
+
+// refreshPaths compute watched paths from the resolved config params, paths resolved after start are watched
+// from their current state and paths no longer resolved nor candidates are dropped
+func (w *watcher) refreshPaths() {
+	w.components = watchedPaths(w.config, w.nc.params)
+	w.paths = make([]string, 0, len(w.components))
+	states := make(map[string]pathState, len(w.components))
+	for path := range w.components {
+		w.paths = append(w.paths, path)
+		if state, ok := w.states[path]; ok {
+			states[path] = state
+			continue
+		}
+		states[path] = statePath(w.nc.hostRoot, path)
+	}
+	w.states = states
+	sort.Strings(w.paths)
+}
+
+// watchedPaths every candidate path of the node config components, not only the resolved ones, so alternate
+// paths created after start are watched, and absolute paths resolved from node config, example:
+// $kubelet.confs /var/lib/kubelet/config.yaml. glob patterns are not watched
+func watchedPaths(config *Config, params map[string]string) map[string][]string {
+	components := make(map[string][]string)
+	add := func(path string, component string) {
+		if !filepath.IsAbs(path) || strings.ContainsAny(path, "*?[") || contains(components[path], component) {
+			return
+		}
+		components[path] = append(components[path], component)
+	}
+	for component, p := range config.Node.Components() {
+		for _, path := range p.Paths() {
+			add(path, component)
+		}
+	}
+	for name, value := range params {
+		component, _, _ := strings.Cut(strings.TrimPrefix(name, "$"), ".")
+		add(value, component)
+	}
+	for path := range components {
+		sort.Strings(components[path])
+	}
+	return components
+}
+
+// referencingCommands commands which audit contain the path, or probes which arg reference a component
+// configured with the path, example: staticpod probe apiserver and $apiserver.confs
+func referencingCommands(commands []Command, path string, components []string) []Command {
+	referencing := make([]Command, 0)
+	for _, c := range commands {
+		if strings.Contains(c.Audit, path) {
+			referencing = append(referencing, c)
+			continue
+		}
+		if c.Probe == "" {
+			continue
+		}
+		for _, component := range components {
+			if probeReferences(c.Audit, component) {
+				referencing = append(referencing, c)
+				break
+			}
+		}
+	}
+	return referencing
+}
+
+// probeReferences whether probe arg reference component, example: apiserver,scheduler or apiserver.flags["--profiling"]
+func probeReferences(arg string, component string) bool {
+	for _, entry := range strings.Split(arg, ",") {
+		entry = strings.TrimSpace(entry)
+		if entry == component || strings.HasPrefix(entry, component+".") {
+			return true
+		}
+	}
+	return false
+}
+
+func statePath(root string, path string) pathState {
+	state := statPath(root, path)
+	if state.mode.IsRegular() {
+		state.hash = hashPath(root, path)
+	}
+	return state
+}
+
+// statPath path state without content hash
+func statPath(root string, path string) pathState {
+	fi, err := os.Stat(filepath.Join(root, path))
+	if err != nil {
+		return pathState{}
+	}
+	state := pathState{exists: true, size: fi.Size(), modTime: fi.ModTime(), mode: fi.Mode()}
+	if st, ok := fi.Sys().(*syscall.Stat_t); ok {
+		state.inode, state.uid, state.gid = uint64(st.Ino), st.Uid, st.Gid
+	}
+	return state
+}
+
+func hashPath(root string, path string) string {
+	data, err := os.ReadFile(filepath.Join(root, path))
+	if err != nil {
+		return ""
+	}
+	sum := sha256.Sum256(data)
+	return hex.EncodeToString(sum[:])
+}
+
+// pollState path state, regular files content is only hashed again when their inode, size or mtime changed
+func pollState(root string, path string, previous pathState) pathState {
+	state := statPath(root, path)
+	if !state.mode.IsRegular() {
+		return state
+	}
+	if previous.exists && previous.inode == state.inode && previous.size == state.size && previous.modTime.Equal(state.modTime) {
+		state.hash = previous.hash
+		return state
+	}
+	state.hash = hashPath(root, path)
+	return state
+}
+
+// poll compare paths state with the previous poll and write an event per changed path, config params and
+// commands are resolved again on change as a created or deleted path may change the resolved config paths,
+// watched paths are then computed again from the resolved params
+func (w *watcher) poll(ctx context.Context, writer io.Writer) error {
+	resolved := false
+	defer func() {
+		if resolved {
+			w.refreshPaths()
+		}
+	}()
+	for _, path := range w.paths {
+		previous := w.states[path]
+		current := pollState(w.nc.hostRoot, path, previous)
+		change := pathChange(previous, current)
+		if change == "" {
+			continue
+		}
+		w.states[path] = current
+		if !resolved {
+			if err := w.nc.resolve(ctx); err != nil {
+				return err
+			}
+			resolved = true
+		}
+		event := WatchEvent{Time: time.Now().UTC().Format(time.RFC3339), Path: path, Change: change, Hash: current.hash}
+		if commands := referencingCommands(w.nc.commands, path, w.components[path]); len(commands) > 0 {
+			info, err := executeCommands(w.nc.shell, ProbeContext{Root: w.nc.hostRoot, Params: w.nc.params}, commands, nil)
+			if err != nil {
+				return err
+			}
+			w.nc.redactor.redactInfo(info)
+			event.Info = info
+		}
+		data, err := json.Marshal(event)
+		if err != nil {
+			return err
+		}
+		fmt.Fprintln(writer, string(data))
+	}
+	return nil
+}
+
+func pathChange(previous pathState, current pathState) string {
+	switch {
+	case !previous.exists && current.exists:
+		return changeCreated
+	case previous.exists && !current.exists:
+		return changeDeleted
+	case previous != current:
+		return changeModified
+	}
+	return ""
+}
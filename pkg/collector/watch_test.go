@@ -0,0 +1,166 @@
+package collector
+
+import (
+	"context"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestWatcherPoll(t *testing.T) {
+	root := writeFiles(t, map[string]string{
+		"/var/lib/kubelet/config.yaml":                  "readOnlyPort: 0\n",
+		"/etc/kubernetes/manifests/kube-apiserver.yaml": apiserverManifest,
+	})
+	redactor, err := newRedactor(nil)
+	assert.NoError(t, err)
+	shell := fakeShell{
+		"stat -c %a /var/lib/kubelet/config.yaml":          "600",
+		"ls /var/lib/kubelet/config.yaml":                  "/var/lib/kubelet/config.yaml",
+		"ls /etc/kubernetes/manifests/kube-apiserver.yaml": "/etc/kubernetes/manifests/kube-apiserver.yaml",
+	}
+	nc := &nodeCollector{
+		hostRoot: root,
+		nodeType: "master",
+		shell:    shell,
+		nodeConfig: []byte(`node:
+  apiserver:
+    confs:
+      - /etc/kubernetes/manifests/kube-apiserver.yaml
+  scheduler:
+    confs:
+      - /etc/kubernetes/manifests/kube-scheduler.yaml
+    defaultconf: /etc/kubernetes/manifests/kube-scheduler.yaml
+  kubelet:
+    confs:
+      - /var/lib/kubelet/config.yaml
+      - /etc/kubernetes/kubelet-config.yaml
+`),
+		nodeCommands: []byte(`commands:
+  - key: kubeletConfFilePermissions
+    nodeType: worker
+    audit: stat -c %a $kubelet.confs
+  - key: kubeAPIServerAnonymousAuthFlag
+    nodeType: master
+    probe: flag
+    audit: apiserver.flags["--anonymous-auth"]
+  - key: schedulerStaticPod
+    nodeType: master
+    probe: staticpod
+    audit: scheduler
+`),
+		redactor: redactor,
+	}
+	assert.NoError(t, nc.resolve(context.Background()))
+	w, err := newWatcher(nc)
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"/etc/kubernetes/kubelet-config.yaml", "/etc/kubernetes/manifests/kube-apiserver.yaml", "/etc/kubernetes/manifests/kube-scheduler.yaml", "/var/lib/kubelet/config.yaml"}, w.paths)
+
+	poll := func() []WatchEvent {
+		buff := new(strings.Builder)
+		assert.NoError(t, w.poll(context.Background(), buff))
+		events := make([]WatchEvent, 0)
+		for _, line := range strings.Split(strings.TrimSpace(buff.String()), "\n") {
+			if line == "" {
+				continue
+			}
+			var event WatchEvent
+			assert.NoError(t, json.Unmarshal([]byte(line), &event))
+			event.Time = ""
+			events = append(events, event)
+		}
+		return events
+	}
+	assert.Empty(t, poll())
+
+	manifest := strings.Replace(apiserverManifest, "--anonymous-auth=false", "--anonymous-auth=true", 1)
+	assert.NoError(t, os.WriteFile(filepath.Join(root, "/etc/kubernetes/manifests/kube-apiserver.yaml"), []byte(manifest), 0o600))
+	assert.NoError(t, os.Chmod(filepath.Join(root, "/var/lib/kubelet/config.yaml"), 0o644))
+	assert.NoError(t, os.WriteFile(filepath.Join(root, "/etc/kubernetes/manifests/kube-scheduler.yaml"), []byte("kind: Pod\n"), 0o600))
+	events := poll()
+	assert.Len(t, events, 3)
+	assert.Equal(t, "/etc/kubernetes/manifests/kube-apiserver.yaml", events[0].Path)
+	assert.Equal(t, changeModified, events[0].Change)
+	assert.NotEmpty(t, events[0].Hash)
+	assert.Equal(t, map[string]*Info{"kubeAPIServerAnonymousAuthFlag": {Values: []interface{}{"true"}}}, events[0].Info)
+	assert.Equal(t, "/etc/kubernetes/manifests/kube-scheduler.yaml", events[1].Path)
+	assert.Equal(t, changeCreated, events[1].Change)
+	assert.Contains(t, events[1].Info, "schedulerStaticPod")
+	assert.Equal(t, WatchEvent{
+		Path:   "/var/lib/kubelet/config.yaml",
+		Change: changeModified,
+		Hash:   events[2].Hash,
+		Info:   map[string]*Info{"kubeletConfFilePermissions": {Values: []interface{}{float64(600)}}},
+	}, events[2])
+
+	assert.Empty(t, poll())
+	assert.NoError(t, os.Remove(filepath.Join(root, "/var/lib/kubelet/config.yaml")))
+	events = poll()
+	assert.Len(t, events, 1)
+	assert.Equal(t, changeDeleted, events[0].Change)
+	assert.Empty(t, events[0].Hash)
+
+	// alternate config path created after start is resolved and its commands re-run
+	delete(shell, "ls /var/lib/kubelet/config.yaml")
+	shell["ls /etc/kubernetes/kubelet-config.yaml"] = "/etc/kubernetes/kubelet-config.yaml"
+	shell["stat -c %a /etc/kubernetes/kubelet-config.yaml"] = "644"
+	assert.NoError(t, os.WriteFile(filepath.Join(root, "/etc/kubernetes/kubelet-config.yaml"), []byte("readOnlyPort: 0\n"), 0o600))
+	events = poll()
+	assert.Equal(t, []WatchEvent{{
+		Path:   "/etc/kubernetes/kubelet-config.yaml",
+		Change: changeCreated,
+		Hash:   events[0].Hash,
+		Info:   map[string]*Info{"kubeletConfFilePermissions": {Values: []interface{}{float64(644)}}},
+	}}, events)
+}
+
+func TestWatcherRefreshPaths(t *testing.T) {
+	root := writeFiles(t, map[string]string{"/var/lib/kubelet/config.yaml": "readOnlyPort: 0\n"})
+	redactor, err := newRedactor(nil)
+	assert.NoError(t, err)
+	shell := fakeShell{"ls /var/lib/kubelet/config.yaml": "/var/lib/kubelet/config.yaml"}
+	nc := &nodeCollector{
+		hostRoot: root,
+		nodeType: "worker",
+		shell:    shell,
+		nodeConfig: []byte(`node:
+  kubelet:
+    confs:
+      - /var/lib/kubelet/config.yaml
+    cafile:
+      - /var/lib/rancher/k3s/agent/client-ca.crt
+`),
+		nodeCommands: []byte(`commands:
+  - key: kubeletConfFilePermissions
+    nodeType: worker
+    audit: stat -c %a $kubelet.confs
+`),
+		redactor: redactor,
+	}
+	assert.NoError(t, nc.resolve(context.Background()))
+	w, err := newWatcher(nc)
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"/var/lib/kubelet/config.yaml", "/var/lib/rancher/k3s/agent/client-ca.crt"}, w.paths)
+
+	// same size content written with the previous mtime is not hashed again
+	path := filepath.Join(root, "/var/lib/kubelet/config.yaml")
+	previous := w.states["/var/lib/kubelet/config.yaml"]
+	assert.NoError(t, os.WriteFile(path, []byte("readOnlyPort: 1\n"), 0o600))
+	assert.NoError(t, os.Chtimes(path, previous.modTime, previous.modTime))
+	buff := new(strings.Builder)
+	assert.NoError(t, w.poll(context.Background(), buff))
+	assert.Empty(t, buff.String())
+	assert.Equal(t, previous.hash, w.states["/var/lib/kubelet/config.yaml"].hash)
+
+	// ca file created after start resolve its directory, which is watched from then on
+	shell["ls /var/lib/rancher/k3s/agent/client-ca.crt"] = "/var/lib/rancher/k3s/agent/client-ca.crt"
+	assert.NoError(t, os.MkdirAll(filepath.Join(root, "/var/lib/rancher/k3s/agent"), 0o755))
+	assert.NoError(t, os.WriteFile(filepath.Join(root, "/var/lib/rancher/k3s/agent/client-ca.crt"), []byte("ca"), 0o600))
+	assert.NoError(t, w.poll(context.Background(), buff))
+	assert.Equal(t, []string{"/var/lib/kubelet/config.yaml", "/var/lib/rancher/k3s/agent", "/var/lib/rancher/k3s/agent/client-ca.crt"}, w.paths)
+	assert.True(t, w.states["/var/lib/rancher/k3s/agent"].exists)
+}
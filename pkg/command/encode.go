@@ -0,0 +1,43 @@
+package cmd
+
+import (
+	"github.com/aquasecurity/k8s-node-collector/pkg/collector"
+	"github.com/spf13/cobra"
+)
+
+const (
+	subCommandEncode = "encode"
+	subCommandDecode = "decode"
+)
+
+func init() {
+	encodeCmd.Flags().StringP("compression", "", collector.CompressionBzip2, "payload compression. One of bzip2|gzip|none")
+	rootCmd.AddCommand(encodeCmd)
+	rootCmd.AddCommand(decodeCmd)
+}
+
+var encodeCmd = &cobra.Command{
+	Use:     subCommandEncode + " <file>",
+	Example: "node-collector encode ./commands.yaml",
+	Short:   "encode a file to a node-collector payload",
+	Long:    `Compress and base64 encode a commands, node config, mapping or kubelet config file (- for stdin) to be passed as node-collector payload flag`,
+	Args:    cobra.ExactArgs(1),
+	RunE: func() func(cmd *cobra.Command, args []string) error {
+		return func(cmd *cobra.Command, args []string) error {
+			return collector.EncodeFile(cmd, args[0])
+		}
+	}(),
+}
+
+var decodeCmd = &cobra.Command{
+	Use:     subCommandDecode + " <payload>",
+	Example: "node-collector decode @./payload.txt",
+	Short:   "decode a node-collector payload",
+	Long:    `Decode a node-collector payload (inline, @file or - for stdin), print it content and report which kind of payload it is (node-commands, node-config, kubelet-config-mapping or kubelet-config)`,
+	Args:    cobra.ExactArgs(1),
+	RunE: func() func(cmd *cobra.Command, args []string) error {
+		return func(cmd *cobra.Command, args []string) error {
+			return collector.DecodePayload(cmd, args[0])
+		}
+	}(),
+}
@@ -10,9 +10,20 @@ const (
 )
 
 func init() {
+	k8sCmd.Flags().BoolP("dry-run", "", false, "print resolved commands per key without executing them")
+	addCollectFlags(k8sCmd)
 	rootCmd.AddCommand(k8sCmd)
 }
 
+// addCollectFlags flags shared by the commands collecting node data
+func addCollectFlags(c *cobra.Command) {
+	c.Flags().StringP("node-type", "", "", "comma separated node roles overriding detection. One or more of master|worker|etcd")
+	c.Flags().StringP("host-root", "", collector.DefaultHostRoot, "node root file system path used to read host facts and detect platform")
+	c.Flags().BoolP("host-facts", "", false, "add host facts (kernel, os release, cgroup, security modules) to the output facts section")
+	c.Flags().StringSliceP("redact", "", []string{}, "additional regex redacting collected values, only the first sub expression is redacted when set")
+	c.Flags().StringP("cache-dir", "", "", "directory of the result cache, commands are only executed when the files they reference changed. disabled when empty")
+}
+
 var k8sCmd = &cobra.Command{
 	Use:   subCommandK8s,
 	Short: "k8s-node-collector extract file system info from cluster Node",
@@ -0,0 +1,27 @@
+package cmd
+
+import (
+	"github.com/aquasecurity/k8s-node-collector/pkg/collector"
+	"github.com/spf13/cobra"
+)
+
+const (
+	subCommandLint = "lint"
+)
+
+func init() {
+	rootCmd.AddCommand(lintCmd)
+}
+
+var lintCmd = &cobra.Command{
+	Use:          subCommandLint + " [files]",
+	Example:      "node-collector lint ./commands.yaml ./config.yaml ./kubeletconfig-mapping.yaml",
+	Short:        "validate spec, node config and kubelet config mapping files",
+	Long:         `Validate spec, node config and kubelet config mapping files and report line numbered diagnostics, the built-in files are validated when no file is given`,
+	SilenceUsage: true,
+	RunE: func() func(cmd *cobra.Command, args []string) error {
+		return func(cmd *cobra.Command, args []string) error {
+			return collector.Lint(cmd, args)
+		}
+	}(),
+}
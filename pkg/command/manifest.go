@@ -0,0 +1,30 @@
+package cmd
+
+import (
+	"github.com/aquasecurity/k8s-node-collector/pkg/collector"
+	"github.com/spf13/cobra"
+)
+
+const (
+	subCommandManifest = "manifest"
+)
+
+func init() {
+	manifestCmd.Flags().StringP("kind", "", collector.ManifestKindJob, "Manifest workload kind. One of job|daemonset")
+	manifestCmd.Flags().StringP("name", "", "node-collector", "workload, service account and cluster role name")
+	manifestCmd.Flags().StringP("namespace", "", "default", "workload namespace")
+	manifestCmd.Flags().StringP("image", "", "ghcr.io/aquasecurity/node-collector:latest", "node-collector image")
+	rootCmd.AddCommand(manifestCmd)
+}
+
+var manifestCmd = &cobra.Command{
+	Use:     subCommandManifest,
+	Example: "node-collector manifest --kind daemonset --spec-name k8s-cis --spec-version 1.23.0",
+	Short:   "generate node-collector Job or DaemonSet and RBAC manifests",
+	Long:    `Generate node-collector Job or DaemonSet and RBAC manifests with embedded payloads, host path volumes derived from node config and spec commands, the node root file system mounted on /host, and hostPID set when the spec inspect host processes`,
+	RunE: func() func(cmd *cobra.Command, args []string) error {
+		return func(cmd *cobra.Command, args []string) error {
+			return collector.GenerateManifest(cmd)
+		}
+	}(),
+}
@@ -1,9 +1,10 @@
 package cmd
 
 import (
-	"fmt"
+	"log/slog"
 	"os"
 
+	"github.com/aquasecurity/k8s-node-collector/pkg/collector"
 	"github.com/spf13/cobra"
 )
 
@@ -13,11 +14,19 @@ func init() {
 	rootCmd.PersistentFlags().StringP("spec-version", "v", "", "spec version. example 1.23.0")
 	rootCmd.PersistentFlags().StringP("cluster-version", "c", "", "cluser version. example 1.23.0")
 	rootCmd.PersistentFlags().StringP("node", "n", "", "node name")
-	rootCmd.PersistentFlags().StringP("kubelet-config", "", "", "kubelet config via api /api/v1/nodes/<>/proxy/configz encoded to base64")
+	rootCmd.PersistentFlags().StringP("kubelet-config", "", "", "kubelet config via api /api/v1/nodes/<>/proxy/configz encoded to base64, plain json, @file or - for stdin")
 	rootCmd.PersistentFlags().StringP("spec-version-mapping", "", "", "k8s spec-version mapping encoded to base64")
-	rootCmd.PersistentFlags().StringP("node-config", "", "", "k8s node file config encoded to base64")
-	rootCmd.PersistentFlags().StringP("node-commands", "", "", "k8s node commands to be executed encoded to base64")
-	rootCmd.PersistentFlags().StringP("kubelet-config-mapping", "", "", "kubelet config api mapping encoded to base64")
+	rootCmd.PersistentFlags().StringP("node-config", "", "", "k8s node file config encoded to base64, plain yaml, @file or - for stdin. default to the built-in node config")
+	rootCmd.PersistentFlags().StringP("node-commands", "", "", "k8s node commands to be executed encoded to base64, plain yaml, @file or - for stdin. default to the built-in spec matching the node platform and version")
+	rootCmd.PersistentFlags().StringP("kubelet-config-mapping", "", "", "kubelet config api mapping encoded to base64, plain yaml, @file or - for stdin")
+	rootCmd.PersistentFlags().StringP("node-config-signature", "", "", "ed25519 signature of node file config encoded to base64")
+	rootCmd.PersistentFlags().StringP("node-commands-signature", "", "", "ed25519 signature of node commands encoded to base64")
+	rootCmd.PersistentFlags().StringP("kubelet-config-mapping-signature", "", "", "ed25519 signature of kubelet config api mapping encoded to base64")
+	rootCmd.PersistentFlags().StringSliceP("public-key", "", []string{}, "ed25519 public key encoded to base64 used to verify payload signatures")
+	rootCmd.PersistentFlags().StringSliceP("public-key-file", "", []string{}, "file with ed25519 public keys (PEM or base64 per line) used to verify payload signatures")
+	rootCmd.PersistentFlags().BoolP("require-signature", "", false, "refuse to run unsigned node config, node commands and kubelet config mapping payloads")
+	rootCmd.PersistentFlags().StringP("log-level", "", "info", "log level. One of debug|info|warn|error, debug trace every executed command")
+	rootCmd.PersistentFlags().StringP("log-format", "", "text", "log format written to stderr. One of text|json")
 }
 
 var rootCmd = &cobra.Command{
@@ -25,6 +34,11 @@ var rootCmd = &cobra.Command{
 	Example: "node-collector k8s [flags]",
 	Short:   "trivy-collector extract file system info",
 	Long:    `A tool which provide a way to extract file info which is not accessible via pre-define commands`,
+	// errors are logged by Execute
+	SilenceErrors: true,
+	PersistentPreRunE: func(cmd *cobra.Command, args []string) error {
+		return collector.SetupLogging(cmd)
+	},
 	RunE: func() func(cmd *cobra.Command, args []string) error {
 		return func(cmd *cobra.Command, args []string) error {
 			return cmd.Help()
@@ -35,7 +49,7 @@ var rootCmd = &cobra.Command{
 // Execute CLI commands
 func Execute() {
 	if err := rootCmd.Execute(); err != nil {
-		fmt.Println(err)
+		slog.Error("command failed", "error", err)
 		os.Exit(1)
 	}
 }
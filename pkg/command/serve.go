@@ -0,0 +1,30 @@
+package cmd
+
+import (
+	"time"
+
+	"github.com/aquasecurity/k8s-node-collector/pkg/collector"
+	"github.com/spf13/cobra"
+)
+
+const (
+	subCommandServe = "serve"
+)
+
+func init() {
+	serveCmd.Flags().StringP("listen", "", ":8080", "address serving /nodeinfo, /healthz, /readyz and /metrics")
+	serveCmd.Flags().DurationP("interval", "", 5*time.Minute, "interval between node info collections")
+	addCollectFlags(serveCmd)
+	rootCmd.AddCommand(serveCmd)
+}
+
+var serveCmd = &cobra.Command{
+	Use:   subCommandServe,
+	Short: "collect node info on an interval and serve it over HTTP",
+	Long:  `Run the collector as a long running process (for example in a DaemonSet), the latest node info is served at /nodeinfo with an ETag and collection metrics at /metrics`,
+	RunE: func() func(cmd *cobra.Command, args []string) error {
+		return func(cmd *cobra.Command, args []string) error {
+			return collector.Serve(cmd)
+		}
+	}(),
+}
@@ -0,0 +1,65 @@
+package cmd
+
+import (
+	"github.com/aquasecurity/k8s-node-collector/pkg/collector"
+	"github.com/spf13/cobra"
+)
+
+const (
+	subCommandSpec = "spec"
+)
+
+func init() {
+	specCmd.AddCommand(specListCmd)
+	specCmd.AddCommand(specShowCmd)
+	specCmd.AddCommand(specExplainCmd)
+	rootCmd.AddCommand(specCmd)
+}
+
+var specCmd = &cobra.Command{
+	Use:     subCommandSpec,
+	Example: "node-collector spec list",
+	Short:   "browse built-in collector specifications",
+	Long:    `Browse built-in collector specifications: list them, show their resolved commands and explain a command`,
+	RunE: func() func(cmd *cobra.Command, args []string) error {
+		return func(cmd *cobra.Command, args []string) error {
+			return cmd.Help()
+		}
+	}(),
+}
+
+var specListCmd = &cobra.Command{
+	Use:     "list",
+	Example: "node-collector spec list -o table",
+	Short:   "list built-in specs name, version, platforms and command count",
+	Args:    cobra.NoArgs,
+	RunE: func() func(cmd *cobra.Command, args []string) error {
+		return func(cmd *cobra.Command, args []string) error {
+			return collector.ListSpecs(cmd)
+		}
+	}(),
+}
+
+var specShowCmd = &cobra.Command{
+	Use:     "show <name>",
+	Example: "node-collector spec show k8s-cis-1.23.0",
+	Short:   "show built-in spec commands resolved with node config default values",
+	Args:    cobra.ExactArgs(1),
+	RunE: func() func(cmd *cobra.Command, args []string) error {
+		return func(cmd *cobra.Command, args []string) error {
+			return collector.ShowSpec(cmd, args[0])
+		}
+	}(),
+}
+
+var specExplainCmd = &cobra.Command{
+	Use:     "explain <key>",
+	Example: "node-collector spec explain kubeletConfFilePermissions",
+	Short:   "explain a spec command title, audit, node type, kubelet config mapping and variables",
+	Args:    cobra.ExactArgs(1),
+	RunE: func() func(cmd *cobra.Command, args []string) error {
+		return func(cmd *cobra.Command, args []string) error {
+			return collector.ExplainCommand(cmd, args[0])
+		}
+	}(),
+}
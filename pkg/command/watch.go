@@ -0,0 +1,29 @@
+package cmd
+
+import (
+	"time"
+
+	"github.com/aquasecurity/k8s-node-collector/pkg/collector"
+	"github.com/spf13/cobra"
+)
+
+const (
+	subCommandWatch = "watch"
+)
+
+func init() {
+	watchCmd.Flags().DurationP("interval", "", 2*time.Second, "interval between node config paths polls")
+	addCollectFlags(watchCmd)
+	rootCmd.AddCommand(watchCmd)
+}
+
+var watchCmd = &cobra.Command{
+	Use:   subCommandWatch,
+	Short: "stream node config changes as NDJSON events",
+	Long:  `Poll the node config paths (mtime, mode, ownership and content hash), re-run the commands referencing a changed path and print a change event per line`,
+	RunE: func() func(cmd *cobra.Command, args []string) error {
+		return func(cmd *cobra.Command, args []string) error {
+			return collector.Watch(cmd)
+		}
+	}(),
+}